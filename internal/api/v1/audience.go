@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/http"
+	"program/internal/model"
+	"program/internal/response"
+	"program/internal/validate"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Relationships) CreateAudienceList(c *gin.Context) {
+	request := new(model.AudienceListPost)
+	if !validate.ValidateRequest(c, request) {
+		return
+	}
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	list, err := h.service.CreateAudienceList(c, userId.(string), request)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "create audience list successfully", list)
+}
+
+func (h *Relationships) GetAudienceLists(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	lists, err := h.service.GetAudienceLists(c, userId.(string))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not get audience lists")
+		return
+	}
+	response.SuccessResponse(c, "get audience lists successfully", lists)
+}
+
+func (h *Relationships) DeleteAudienceList(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	listId := c.Param("listId")
+	if _, err := uuid.Parse(listId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "list id is not a valid UUID")
+		return
+	}
+	if err := h.service.DeleteAudienceList(c, userId.(string), listId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "delete audience list successfully", "")
+}
+
+func (h *Relationships) GetAudienceMembers(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	listId := c.Param("listId")
+	if _, err := uuid.Parse(listId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "list id is not a valid UUID")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "limit is a number")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "offset is a number")
+		return
+	}
+	members, err := h.service.GetAudienceMembers(c, limit, offset, userId.(string), listId)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "get audience members successfully", members)
+}
+
+func (h *Relationships) AddAudienceMembers(c *gin.Context) {
+	h.changeAudienceMembers(c, true)
+}
+
+func (h *Relationships) RemoveAudienceMembers(c *gin.Context) {
+	h.changeAudienceMembers(c, false)
+}
+
+func (h *Relationships) changeAudienceMembers(c *gin.Context, add bool) {
+	request := new(model.AudienceMembersPost)
+	if !validate.ValidateRequest(c, request) {
+		return
+	}
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	listId := c.Param("listId")
+	if _, err := uuid.Parse(listId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "list id is not a valid UUID")
+		return
+	}
+	if add {
+		if err := h.service.AddAudienceMembers(c, userId.(string), listId, request); err != nil {
+			response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+			return
+		}
+		response.SuccessResponse(c, "add audience members successfully", "")
+		return
+	}
+	if err := h.service.RemoveAudienceMembers(c, userId.(string), listId, request); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "remove audience members successfully", "")
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"program/internal/middleware"
+	"program/internal/model"
+	"program/internal/response"
+	"program/internal/services"
+	"program/internal/validate"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Bookmark struct {
+	service services.IBookmarkService
+}
+
+func NewBookmarkAPI(engine *gin.Engine, service services.IBookmarkService) {
+	handler := &Bookmark{
+		service: service,
+	}
+	Group := engine.Group("api/v1")
+	{
+		Group.POST("newsfeed/post/:postId/bookmark", middleware.AuthMdw.RequestAuthorization(), handler.AddBookmark)
+		Group.DELETE("newsfeed/post/:postId/bookmark", middleware.AuthMdw.RequestAuthorization(), handler.RemoveBookmark)
+		Group.GET("user/bookmarks", middleware.AuthMdw.RequestAuthorization(), handler.GetBookmarks)
+		Group.GET("user/bookmarks/collections", middleware.AuthMdw.RequestAuthorization(), handler.GetCollections)
+	}
+}
+
+func (h *Bookmark) AddBookmark(c *gin.Context) {
+	bookmark := new(model.BookmarkPost)
+	// The body is optional, posts are saved to the default collection
+	if c.Request.ContentLength != 0 && !validate.ValidateRequest(c, bookmark) {
+		return
+	}
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	if _, err := uuid.Parse(postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
+		return
+	}
+	saved, err := h.service.AddBookmark(c, userId.(string), postId, bookmark)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "bookmark post successfully", saved)
+}
+
+func (h *Bookmark) RemoveBookmark(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	if _, err := uuid.Parse(postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
+		return
+	}
+	if err := h.service.RemoveBookmark(c, userId.(string), postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "remove bookmark successfully", "")
+}
+
+func (h *Bookmark) GetBookmarks(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "limit is a positive number")
+		return
+	}
+	bookmarks, err := h.service.GetBookmarks(c, userId.(string), c.Query("collection"), limit, c.Query("cursor"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "get bookmarks successfully", bookmarks)
+}
+
+func (h *Bookmark) GetCollections(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	collections, err := h.service.GetCollections(c, userId.(string))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not get bookmark collections")
+		return
+	}
+	response.SuccessResponse(c, "get bookmark collections successfully", collections)
+}
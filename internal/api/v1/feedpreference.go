@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"program/internal/middleware"
+	"program/internal/model"
+	"program/internal/response"
+	"program/internal/services"
+	"program/internal/validate"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type FeedPreference struct {
+	service services.IFeedPreferenceService
+}
+
+func NewFeedPreferenceAPI(engine *gin.Engine, service services.IFeedPreferenceService) {
+	handler := &FeedPreference{
+		service: service,
+	}
+	Group := engine.Group("api/v1")
+	{
+		Group.POST("newsfeed/post/:postId/hide", middleware.AuthMdw.RequestAuthorization(), handler.HidePost)
+		Group.DELETE("newsfeed/post/:postId/hide", middleware.AuthMdw.RequestAuthorization(), handler.UnhidePost)
+		Group.POST("user/snoozes/:authorId", middleware.AuthMdw.RequestAuthorization(), handler.SnoozeAuthor)
+		Group.DELETE("user/snoozes/:authorId", middleware.AuthMdw.RequestAuthorization(), handler.UnsnoozeAuthor)
+		Group.GET("user/feed-preferences", middleware.AuthMdw.RequestAuthorization(), handler.GetFeedPreferences)
+	}
+}
+
+func (h *FeedPreference) HidePost(c *gin.Context) {
+	hide := new(model.HidePost)
+	// The body is optional, posts are simply hidden without a reason
+	if c.Request.ContentLength != 0 && !validate.ValidateRequest(c, hide) {
+		return
+	}
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	if _, err := uuid.Parse(postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
+		return
+	}
+	if err := h.service.HidePost(c, userId.(string), postId, hide); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "hide post successfully", "")
+}
+
+func (h *FeedPreference) UnhidePost(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	if _, err := uuid.Parse(postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
+		return
+	}
+	if err := h.service.UnhidePost(c, userId.(string), postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "unhide post successfully", "")
+}
+
+func (h *FeedPreference) SnoozeAuthor(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	authorId := c.Param("authorId")
+	if _, err := uuid.Parse(authorId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "author id is not a valid UUID")
+		return
+	}
+	snooze, err := h.service.SnoozeAuthor(c, userId.(string), authorId)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "snooze author successfully", snooze)
+}
+
+func (h *FeedPreference) UnsnoozeAuthor(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	authorId := c.Param("authorId")
+	if _, err := uuid.Parse(authorId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "author id is not a valid UUID")
+		return
+	}
+	if err := h.service.UnsnoozeAuthor(c, userId.(string), authorId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "unsnooze author successfully", "")
+}
+
+func (h *FeedPreference) GetFeedPreferences(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "limit is a number")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "offset is a number")
+		return
+	}
+	preferences, err := h.service.GetFeedPreferences(c, userId.(string), limit, offset)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not get feed preferences")
+		return
+	}
+	response.SuccessResponse(c, "get feed preferences successfully", preferences)
+}
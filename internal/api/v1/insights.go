@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"program/internal/middleware"
+	"program/internal/model"
+	"program/internal/response"
+	"program/internal/services"
+	"program/internal/validate"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Insights struct {
+	service services.IInsightsService
+}
+
+func NewInsightsAPI(engine *gin.Engine, service services.IInsightsService) {
+	handler := &Insights{
+		service: service,
+	}
+	Group := engine.Group("api/v1")
+	{
+		Group.POST("newsfeed/impressions", middleware.AuthMdw.RequestAuthorization(), handler.RecordImpressions)
+		Group.GET("newsfeed/insights", middleware.AuthMdw.RequestAuthorization(), handler.GetInsights)
+		Group.GET("newsfeed/post/:postId/insights", middleware.AuthMdw.RequestAuthorization(), handler.GetPostInsights)
+	}
+}
+
+func (h *Insights) RecordImpressions(c *gin.Context) {
+	batch := new(model.ImpressionBatch)
+	if !validate.ValidateRequest(c, batch) {
+		return
+	}
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	recorded, err := h.service.RecordImpressions(c, userId.(string), batch)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not record impressions")
+		return
+	}
+	response.SuccessResponse(c, "record impressions successfully", recorded)
+}
+
+func (h *Insights) GetPostInsights(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	if _, err := uuid.Parse(postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 90 {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "days is a number between 1 and 90")
+		return
+	}
+	insights, err := h.service.GetPostInsights(c, userId.(string), postId, days)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "get post insights successfully", insights)
+}
+
+func (h *Insights) GetInsights(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "limit is a number")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "offset is a number")
+		return
+	}
+	insights, err := h.service.GetInsights(c, userId.(string), limit, offset)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not get insights")
+		return
+	}
+	response.SuccessResponseWithPagination(c, limit, offset, "get insights successfully", insights)
+}
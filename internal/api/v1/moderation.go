@@ -0,0 +1,187 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"program/internal/middleware"
+	"program/internal/model"
+	"program/internal/response"
+	"program/internal/services"
+	"program/internal/validate"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Moderation struct {
+	service services.IModerationService
+}
+
+func NewModerationAPI(engine *gin.Engine, service services.IModerationService) {
+	handler := &Moderation{
+		service: service,
+	}
+	Group := engine.Group("api/v1")
+	{
+		Group.POST("reports", middleware.AuthMdw.RequestAuthorization(), handler.Report)
+
+		//moderators only
+		Group.GET("moderation/reports", middleware.AuthMdw.RequestAuthorization(), handler.GetQueue)
+		Group.GET("moderation/reports/:targetType/:targetId", middleware.AuthMdw.RequestAuthorization(), handler.GetTargetReports)
+		Group.POST("moderation/reports/:targetType/:targetId/decision", middleware.AuthMdw.RequestAuthorization(), handler.Decide)
+		Group.GET("moderation/actions", middleware.AuthMdw.RequestAuthorization(), handler.GetActions)
+	}
+}
+
+// moderationError answers 403 to regular users and 400 otherwise
+func moderationError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrNotModerator) {
+		response.ErrorResponse[string](c, http.StatusForbidden, err.Error())
+		return
+	}
+	response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+}
+
+// pageQuery reads the limit and offset query parameters
+func pageQuery(c *gin.Context) (int, int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "limit is a number")
+		return 0, 0, false
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "offset is a number")
+		return 0, 0, false
+	}
+	return limit, offset, true
+}
+
+// reportTarget reads and checks the :targetType/:targetId path parameters
+func reportTarget(c *gin.Context) (model.ReportTarget, string, bool) {
+	targetType := model.ReportTarget(c.Param("targetType"))
+	switch targetType {
+	case model.ReportedPost, model.ReportedComment, model.ReportedProfile:
+	default:
+		response.ErrorResponse[string](c, http.StatusBadRequest, "target type must be post, comment or profile")
+		return "", "", false
+	}
+	targetId := c.Param("targetId")
+	if _, err := uuid.Parse(targetId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "target id is not a valid UUID")
+		return "", "", false
+	}
+	return targetType, targetId, true
+}
+
+func (h *Moderation) Report(c *gin.Context) {
+	report := new(model.ReportPost)
+	if !validate.ValidateRequest(c, report) {
+		return
+	}
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	result, err := h.service.Report(c, userId.(string), report)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "report content successfully", result)
+}
+
+func (h *Moderation) GetQueue(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	status := model.ReportStatus(c.DefaultQuery("status", string(model.OpenReport)))
+	switch status {
+	case model.OpenReport, model.ResolvedReport, model.DismissedReport:
+	default:
+		response.ErrorResponse[string](c, http.StatusBadRequest, "status must be open, resolved or dismissed")
+		return
+	}
+	limit, offset, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+	queue, err := h.service.GetQueue(c, userId.(string), status, limit, offset)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+	response.SuccessResponse(c, "get moderation queue successfully", queue)
+}
+
+func (h *Moderation) GetTargetReports(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	targetType, targetId, ok := reportTarget(c)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+	reports, err := h.service.GetTargetReports(c, userId.(string), targetType, targetId, limit, offset)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+	response.SuccessResponse(c, "get reports successfully", reports)
+}
+
+func (h *Moderation) Decide(c *gin.Context) {
+	decision := new(model.ModerationDecision)
+	if !validate.ValidateRequest(c, decision) {
+		return
+	}
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	targetType, targetId, ok := reportTarget(c)
+	if !ok {
+		return
+	}
+	action, err := h.service.Decide(c, userId.(string), targetType, targetId, decision)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+	response.SuccessResponse(c, "apply moderation decision successfully", action)
+}
+
+func (h *Moderation) GetActions(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	limit, offset, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+	filter := model.ModerationActionFilter{
+		TargetType:  model.ReportTarget(c.Query("targetType")),
+		TargetId:    c.Query("targetId"),
+		ModeratorId: c.Query("moderatorId"),
+		SubjectId:   c.Query("subjectId"),
+	}
+	actions, err := h.service.GetActions(c, userId.(string), filter, limit, offset)
+	if err != nil {
+		moderationError(c, err)
+		return
+	}
+	response.SuccessResponse(c, "get moderation actions successfully", actions)
+}
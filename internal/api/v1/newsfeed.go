@@ -8,7 +8,9 @@ import (
 	"program/internal/response"
 	"program/internal/services"
 	"program/internal/validate"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -27,18 +29,36 @@ func NewNewsFeedAPI(engine *gin.Engine, service services.INewsfeedService) {
 		//newsfeed
 		Group.GET("", middleware.AuthMdw.RequestAuthorization(), handler.GetNewsfeed)
 		Group.POST("post", middleware.AuthMdw.RequestAuthorization(), handler.CreatePost)
-		Group.PATCH("post/:id", middleware.AuthMdw.RequestAuthorization())
-		Group.DELETE("post/:id", middleware.AuthMdw.RequestAuthorization())
+		Group.POST("media", middleware.AuthMdw.RequestAuthorization(), handler.UploadMedia)
+		Group.PATCH("post/:postId", middleware.AuthMdw.RequestAuthorization(), handler.UpdatePost)
+		Group.DELETE("post/:postId", middleware.AuthMdw.RequestAuthorization(), handler.DeletePost)
+		Group.GET("post/:postId/revisions", middleware.AuthMdw.RequestNoRequiredAuthorization(), handler.GetPostRevisions)
+		Group.GET("drafts", middleware.AuthMdw.RequestAuthorization(), handler.GetDrafts)
+		Group.POST("post/:postId/publish", middleware.AuthMdw.RequestAuthorization(), handler.PublishPost)
 
-		//Group.GET("user/:id/posts", middleware.AuthMdw.RequestAuthorization())
+		Group.GET("user/:id/posts", middleware.AuthMdw.RequestNoRequiredAuthorization(), handler.GetUserPosts)
+		Group.POST("post/:postId/pin", middleware.AuthMdw.RequestAuthorization(), handler.PinPost)
+		Group.DELETE("post/:postId/pin", middleware.AuthMdw.RequestAuthorization(), handler.UnpinPost)
+
+		//hashtags
+		Group.GET("tags/:tag", middleware.AuthMdw.RequestNoRequiredAuthorization(), handler.GetHashtagFeed)
+		Group.GET("trending/tags", middleware.AuthMdw.RequestNoRequiredAuthorization(), handler.GetTrendingTags)
 
 		//interact newsfeed
 		Group.POST("post/:postId/like", middleware.AuthMdw.RequestAuthorization(), handler.ToggleLikePost)
 		Group.GET("post/:postId/like", middleware.AuthMdw.RequestNoRequiredAuthorization(), handler.GetLikers)
+		Group.POST("post/:postId/share", middleware.AuthMdw.RequestAuthorization(), handler.SharePost)
+		Group.POST("post/:postId/vote", middleware.AuthMdw.RequestAuthorization(), handler.VotePoll)
 
 		Group.POST("post/:postId/comment", middleware.AuthMdw.RequestAuthorization(), handler.PostComment)
 		Group.PUT("post/:postId/comment", middleware.AuthMdw.RequestAuthorization(), handler.PutComment)
+		Group.DELETE("post/:postId/comment/:commentId", middleware.AuthMdw.RequestAuthorization(), handler.DeleteComment)
+		Group.GET("post/:postId/comment/:commentId/revisions", middleware.AuthMdw.RequestNoRequiredAuthorization(), handler.GetCommentRevisions)
+		Group.POST("post/:postId/comment/:commentId/hide", middleware.AuthMdw.RequestAuthorization(), handler.HideComment)
+		Group.DELETE("post/:postId/comment/:commentId/hide", middleware.AuthMdw.RequestAuthorization(), handler.UnhideComment)
 		Group.GET("post/:postId/comments", middleware.AuthMdw.RequestAuthorization(), handler.RetrieveComments)
+		Group.GET("post/:postId/comments/:commentId/replies", middleware.AuthMdw.RequestAuthorization(), handler.RetrieveReplies)
+		Group.POST("comment/:commentId/like", middleware.AuthMdw.RequestAuthorization(), handler.ToggleLikeComment)
 
 	}
 }
@@ -55,13 +75,76 @@ func (h *Newsfeed) CreatePost(c *gin.Context) {
 	}
 	mypost, err := h.service.CreatePost(c, userId.(string), newpost)
 	if err != nil {
-		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not create a new post")
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	response.SuccessResponse(c, "create post successfully", mypost)
 }
 
+func (h *Newsfeed) UploadMedia(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	file, err := c.FormFile("media")
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "can not get file from request")
+		return
+	}
+	media, err := h.service.UploadMedia(c, userId.(string), file, c.PostForm("altText"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "upload media successfully", media)
+}
+
+func (h *Newsfeed) UpdatePost(c *gin.Context) {
+	patch := new(model.PostPatch)
+	if !validate.ValidateRequest(c, patch) {
+		return
+	}
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	if _, err := uuid.Parse(postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
+		return
+	}
+	putResponse, err := h.service.UpdatePost(c, userId.(string), postId, patch)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "update post successfully", putResponse)
+}
+
+func (h *Newsfeed) DeletePost(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	if _, err := uuid.Parse(postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
+		return
+	}
+	if err := h.service.DeletePost(c, userId.(string), postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "delete post successfully", "")
+}
+
+// GetNewsfeed pages through the timeline with limit and offset until the
+// response carries a nextCursor. Pages read with a cursor, or limited by since
+// and until, come newest first whatever the mode and take no offset.
 func (h *Newsfeed) GetNewsfeed(c *gin.Context) {
 	userId, existed := c.Get("userId")
 	if !existed || userId == "" {
@@ -78,8 +161,26 @@ func (h *Newsfeed) GetNewsfeed(c *gin.Context) {
 		response.ErrorResponse[string](c, http.StatusBadRequest, "offset is a number")
 		return
 	}
-	newsfeed, err := h.service.GetNewsfeed(c, limit, offset, userId.(string))
+	mode := model.FeedMode(c.DefaultQuery("mode", string(model.LatestFeed)))
+	if mode != model.LatestFeed && mode != model.RankedFeed {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "mode must be latest or ranked")
+		return
+	}
+	span, ok := feedRange(c)
+	if !ok {
+		return
+	}
+	cursor := c.Query("cursor")
+	if (cursor != "" || !span.IsZero()) && offset != 0 {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "offset can not be used with a cursor, since or until")
+		return
+	}
+	newsfeed, err := h.service.GetNewsfeed(c, limit, offset, userId.(string), mode, cursor, span)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+			return
+		}
 		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not get newsfeed")
 		return
 	}
@@ -87,6 +188,168 @@ func (h *Newsfeed) GetNewsfeed(c *gin.Context) {
 
 }
 
+// feedRange reads the optional since and until query parameters, given as
+// RFC 3339 times
+func feedRange(c *gin.Context) (model.FeedRange, bool) {
+	var span model.FeedRange
+	for name, bound := range map[string]*time.Time{"since": &span.Since, "until": &span.Until} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			response.ErrorResponse[string](c, http.StatusBadRequest, name+" must be an RFC 3339 time")
+			return span, false
+		}
+		*bound = parsed
+	}
+	if !span.Since.IsZero() && !span.Until.IsZero() && !span.Since.Before(span.Until) {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "since must be before until")
+		return span, false
+	}
+	return span, true
+}
+
+func (h *Newsfeed) GetDrafts(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "limit is a number")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "offset is a number")
+		return
+	}
+	drafts, err := h.service.GetDrafts(c, limit, offset, userId.(string))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not get drafts")
+		return
+	}
+	response.SuccessResponseWithPagination(c, limit, offset, userId.(string), drafts)
+}
+
+func (h *Newsfeed) PublishPost(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	if _, err := uuid.Parse(postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
+		return
+	}
+	post, err := h.service.PublishPost(c, userId.(string), postId)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "publish post successfully", post)
+}
+
+func (h *Newsfeed) GetUserPosts(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		userId = "guest"
+	}
+	authorId := c.Param("id")
+	if _, err := uuid.Parse(authorId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id is not a valid UUID")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "limit is a number")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "offset is a number")
+		return
+	}
+	posts, err := h.service.GetUserPosts(c, limit, offset, userId.(string), authorId)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not get user posts")
+		return
+	}
+	response.SuccessResponseWithPagination(c, limit, offset, authorId, posts)
+}
+
+func (h *Newsfeed) PinPost(c *gin.Context) {
+	h.setPinned(c, true)
+}
+
+func (h *Newsfeed) UnpinPost(c *gin.Context) {
+	h.setPinned(c, false)
+}
+
+func (h *Newsfeed) setPinned(c *gin.Context, pinned bool) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	if _, err := uuid.Parse(postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
+		return
+	}
+	if err := h.service.SetPostPinned(c, userId.(string), postId, pinned); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "update post successfully", postId)
+}
+
+func (h *Newsfeed) GetHashtagFeed(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		userId = "guest"
+	}
+	tag := c.Param("tag")
+	if tag == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "tag can not be empty")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "limit is a number")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "offset is a number")
+		return
+	}
+	newsfeed, err := h.service.GetHashtagFeed(c, limit, offset, userId.(string), tag)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not get hashtag feed")
+		return
+	}
+	response.SuccessResponseWithPagination(c, limit, offset, tag, newsfeed)
+}
+
+func (h *Newsfeed) GetTrendingTags(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "limit is a number")
+		return
+	}
+	trending, err := h.service.GetTrendingTags(c, limit)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not get trending tags")
+		return
+	}
+	response.SuccessResponse(c, "get trending tags successfully", trending)
+}
+
 func (h *Newsfeed) ToggleLikePost(c *gin.Context) {
 	userId, existed := c.Get("userId")
 	if !existed || userId == "" {
@@ -102,7 +365,12 @@ func (h *Newsfeed) ToggleLikePost(c *gin.Context) {
 		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
 		return
 	}
-	err := h.service.ToggleLikePost(c, userId.(string), postId)
+	reaction := model.Reaction(c.DefaultQuery("reaction", string(model.ReactionLike)))
+	if !slices.Contains(model.Reactions, reaction) {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "unknown reaction")
+		return
+	}
+	err := h.service.ToggleLikePost(c, userId.(string), postId, reaction)
 	if err != nil {
 		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not toggle like or unlike")
 		return
@@ -110,6 +378,75 @@ func (h *Newsfeed) ToggleLikePost(c *gin.Context) {
 	response.SuccessResponse(c, "toggle like post successfully", "")
 }
 
+func (h *Newsfeed) ToggleLikeComment(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	commentId := c.Param("commentId")
+	if commentId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "comment id can not be empty")
+		return
+	}
+	if _, err := uuid.Parse(commentId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "comment id is not a valid UUID")
+		return
+	}
+	err := h.service.ToggleLikeComment(c, userId.(string), commentId)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not toggle like or unlike")
+		return
+	}
+	response.SuccessResponse(c, "toggle like comment successfully", "")
+}
+
+func (h *Newsfeed) SharePost(c *gin.Context) {
+	share := new(model.SharePost)
+	if !validate.ValidateRequest(c, share) {
+		return
+	}
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	if _, err := uuid.Parse(postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
+		return
+	}
+	repost, err := h.service.SharePost(c, userId.(string), postId, share)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "share post successfully", repost)
+}
+
+func (h *Newsfeed) VotePoll(c *gin.Context) {
+	vote := new(model.PollVotePost)
+	if !validate.ValidateRequest(c, vote) {
+		return
+	}
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	if _, err := uuid.Parse(postId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id is not a valid UUID")
+		return
+	}
+	poll, err := h.service.VotePoll(c, userId.(string), postId, vote)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "vote successfully", poll)
+}
+
 func (h *Newsfeed) GetLikers(c *gin.Context) {
 	userId, existed := c.Get("userId")
 	if !existed || userId == "" {
@@ -130,16 +467,17 @@ func (h *Newsfeed) GetLikers(c *gin.Context) {
 		response.ErrorResponse[string](c, http.StatusBadRequest, "offset is a number")
 		return
 	}
+	reaction := model.Reaction(c.Query("reaction"))
 
 	if userId == "guest" {
-		likers, err := h.service.GetLikers(c, limit, offset, userId.(string), postId, true)
+		likers, err := h.service.GetLikers(c, limit, offset, userId.(string), postId, reaction, true)
 		if err != nil {
 			response.ErrorResponse[string](c, http.StatusInternalServerError, err.Error())
 			return
 		}
 		response.SuccessResponseWithPagination(c, limit, offset, userId.(string), likers)
 	} else {
-		likers, err := h.service.GetLikers(c, limit, offset, userId.(string), postId, false)
+		likers, err := h.service.GetLikers(c, limit, offset, userId.(string), postId, reaction, false)
 		if err != nil {
 			response.ErrorResponse[string](c, http.StatusInternalServerError, err.Error())
 			return
@@ -165,7 +503,7 @@ func (h *Newsfeed) PostComment(c *gin.Context) {
 	}
 	mycomment, err := h.service.PostComment(c, user_id.(string), id, newcomment)
 	if err != nil {
-		response.ErrorResponse[string](c, http.StatusInternalServerError, "can not create a new comment")
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
 		return
 	}
 	response.SuccessResponse(c, "create post successfully", mycomment)
@@ -187,7 +525,12 @@ func (h *Newsfeed) RetrieveComments(c *gin.Context) {
 		c.JSON(response.BadRequest(errors.New("offset is a number")))
 		return
 	}
-	getResponse, err := h.service.GetComments(c, limit, offset, id)
+	user_id, existed := c.Get("userId")
+	if !existed {
+		c.JSON(response.BadRequest(errors.New("user id not found")))
+		return
+	}
+	getResponse, err := h.service.GetComments(c, limit, offset, user_id.(string), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, map[string]any{
 			"status":  "error",
@@ -199,12 +542,55 @@ func (h *Newsfeed) RetrieveComments(c *gin.Context) {
 
 }
 
+func (h *Newsfeed) RetrieveReplies(c *gin.Context) {
+	postId := c.Param("postId")
+	commentId := c.Param("commentId")
+	if len(postId) <= 0 || len(commentId) <= 0 {
+		c.JSON(response.BadRequest(errors.New("id is empty")))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		c.JSON(response.BadRequest(errors.New("limit is a number")))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.JSON(response.BadRequest(errors.New("offset is a number")))
+		return
+	}
+	user_id, existed := c.Get("userId")
+	if !existed {
+		c.JSON(response.BadRequest(errors.New("user id not found")))
+		return
+	}
+	getResponse, err := h.service.GetReplies(c, limit, offset, user_id.(string), postId, commentId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, map[string]any{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, getResponse)
+}
+
 func (h *Newsfeed) PutComment(c *gin.Context) {
 	commentPut := new(model.CommentPut)
 	if !validate.ValidateRequest(c, commentPut) {
 		return
 	}
-	putResponse, err := h.service.PutComment(c, commentPut)
+	user_id, existed := c.Get("userId")
+	if !existed {
+		c.JSON(response.BadRequest(errors.New("user id not found")))
+		return
+	}
+	id := c.Param("postId")
+	if len(id) <= 0 {
+		c.JSON(response.BadRequest(errors.New("id is empty")))
+		return
+	}
+	putResponse, err := h.service.PutComment(c, user_id.(string), id, commentPut)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, map[string]any{
 			"status":  "error",
@@ -214,3 +600,86 @@ func (h *Newsfeed) PutComment(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, putResponse)
 }
+
+func (h *Newsfeed) DeleteComment(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	commentId := c.Param("commentId")
+	if postId == "" || commentId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "id can not be empty")
+		return
+	}
+	if err := h.service.DeleteComment(c, userId.(string), postId, commentId); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "delete comment successfully", commentId)
+}
+
+func (h *Newsfeed) HideComment(c *gin.Context) {
+	h.setCommentHidden(c, true)
+}
+
+func (h *Newsfeed) UnhideComment(c *gin.Context) {
+	h.setCommentHidden(c, false)
+}
+
+func (h *Newsfeed) setCommentHidden(c *gin.Context, hidden bool) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "user id not found")
+		return
+	}
+	postId := c.Param("postId")
+	commentId := c.Param("commentId")
+	if postId == "" || commentId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "id can not be empty")
+		return
+	}
+	if err := h.service.SetCommentHidden(c, userId.(string), postId, commentId, hidden); err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "update comment successfully", commentId)
+}
+
+func (h *Newsfeed) GetPostRevisions(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		userId = "guest"
+	}
+	postId := c.Param("postId")
+	if postId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "post id can not be empty")
+		return
+	}
+	revisions, err := h.service.GetPostRevisions(c, userId.(string), postId)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusNotFound, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "get post revisions successfully", revisions)
+}
+
+func (h *Newsfeed) GetCommentRevisions(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		userId = "guest"
+	}
+	postId := c.Param("postId")
+	commentId := c.Param("commentId")
+	if postId == "" || commentId == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "id can not be empty")
+		return
+	}
+	revisions, err := h.service.GetCommentRevisions(c, userId.(string), postId, commentId)
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusNotFound, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "get comment revisions successfully", revisions)
+}
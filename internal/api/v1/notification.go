@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"program/internal/middleware"
+	"program/internal/response"
+	"program/internal/services"
+	"program/internal/validate"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Notification struct {
+	service services.INotificationService
+}
+
+func NewNotificationAPI(engine *gin.Engine, service services.INotificationService) {
+	handler := &Notification{
+		service: service,
+	}
+	Group := engine.Group("api/v1/notifications")
+	{
+		Group.GET("", middleware.AuthMdw.RequestAuthorization(), handler.GetNotifications)
+		Group.POST("read", middleware.AuthMdw.RequestAuthorization(), handler.MarkRead)
+	}
+}
+
+func (h *Notification) GetNotifications(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed {
+		c.JSON(response.BadRequest(errors.New("user id not found")))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		c.JSON(response.BadRequest(errors.New("limit is a number")))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.JSON(response.BadRequest(errors.New("offset is a number")))
+		return
+	}
+	getResponse, err := h.service.GetNotifications(c, limit, offset, userId.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, map[string]any{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, getResponse)
+}
+
+func (h *Notification) MarkRead(c *gin.Context) {
+	var request struct {
+		NotificationIds []string `json:"notificationIds"`
+	}
+	if !validate.ValidateRequest(c, &request) {
+		return
+	}
+	userId, existed := c.Get("userId")
+	if !existed {
+		c.JSON(response.BadRequest(errors.New("user id not found")))
+		return
+	}
+	putResponse, err := h.service.MarkRead(c, userId.(string), request.NotificationIds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, map[string]any{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, putResponse)
+}
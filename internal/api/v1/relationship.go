@@ -26,6 +26,12 @@ func NewRelationshipsAPI(engine *gin.Engine, relationshipsService services.IRela
 		Group.GET(":id/followers", middleware.AuthMdw.RequestAuthorization(), handler.RetrieveFollowers)
 		Group.GET(":id/following", middleware.AuthMdw.RequestAuthorization(), handler.RetrieveFollowing)
 		Group.GET(":id/count-follow", middleware.AuthMdw.RequestAuthorization(), handler.RetrieveNumberOfFollowRelationship)
+		Group.POST("user/audience-lists", middleware.AuthMdw.RequestAuthorization(), handler.CreateAudienceList)
+		Group.GET("user/audience-lists", middleware.AuthMdw.RequestAuthorization(), handler.GetAudienceLists)
+		Group.DELETE("user/audience-lists/:listId", middleware.AuthMdw.RequestAuthorization(), handler.DeleteAudienceList)
+		Group.GET("user/audience-lists/:listId/members", middleware.AuthMdw.RequestAuthorization(), handler.GetAudienceMembers)
+		Group.POST("user/audience-lists/:listId/members", middleware.AuthMdw.RequestAuthorization(), handler.AddAudienceMembers)
+		Group.DELETE("user/audience-lists/:listId/members", middleware.AuthMdw.RequestAuthorization(), handler.RemoveAudienceMembers)
 	}
 }
 func (h *Relationships) RetrieveNumberOfFollowRelationship(c *gin.Context) {
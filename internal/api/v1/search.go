@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"program/internal/middleware"
+	"program/internal/model"
+	"program/internal/response"
+	"program/internal/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Search struct {
+	service services.ISearchService
+}
+
+func NewSearchAPI(engine *gin.Engine, service services.ISearchService) {
+	handler := &Search{
+		service: service,
+	}
+	Group := engine.Group("api/v1/search")
+	{
+		Group.GET("", middleware.AuthMdw.RequestNoRequiredAuthorization(), handler.Search)
+	}
+}
+
+func (h *Search) Search(c *gin.Context) {
+	userId, existed := c.Get("userId")
+	if !existed || userId == "" {
+		userId = "guest"
+	}
+	query := c.Query("q")
+	if query == "" {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "q can not be empty")
+		return
+	}
+	searchType := model.SearchType(c.DefaultQuery("type", string(model.SearchPosts)))
+	if searchType != model.SearchPosts && searchType != model.SearchPeople {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "type must be posts or people")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		response.ErrorResponse[string](c, http.StatusBadRequest, "limit is a positive number")
+		return
+	}
+	results, err := h.service.Search(c, userId.(string), query, searchType, limit, c.Query("cursor"))
+	if err != nil {
+		response.ErrorResponse[string](c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.SuccessResponse(c, "search successfully", results)
+}
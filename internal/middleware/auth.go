@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"program/internal/response"
 	"program/internal/services"
@@ -34,6 +35,11 @@ func (m *AuthorMwd) RequestAuthorization() gin.HandlerFunc {
 			return
 		}
 		tokenClaims, err := m.authen.ValidateToken(c, authHeader, false)
+		if errors.Is(err, services.ErrAccountSuspended) {
+			response.ErrorResponse[string](c, http.StatusForbidden, err.Error())
+			c.Abort()
+			return
+		}
 		if err != nil {
 			response.ErrorResponse[string](c, http.StatusUnauthorized, "invalid or expired token")
 			c.Abort()
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// AudienceList is a named group of users picked by its owner, such as close
+// friends. Posts with Custom privacy are shown to the members of their lists.
+type AudienceList struct {
+	bun.BaseModel `bun:"audience_lists,alias:al"`
+	ListId        string    `json:"listId" bun:"listId,type:varchar(36),pk,notnull"`
+	OwnerId       string    `json:"ownerId" bun:"ownerId,type:varchar(36),notnull"`
+	Name          string    `json:"name" bun:"name,type:varchar(50),notnull"`
+	MemberCount   int       `json:"memberCount" bun:"memberCount,scanonly"`
+	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+type AudienceMember struct {
+	bun.BaseModel `bun:"audience_members"`
+	ListId        string    `json:"listId" bun:"listId,type:varchar(36),pk,notnull"`
+	MemberId      string    `json:"memberId" bun:"memberId,type:varchar(36),pk,notnull"`
+	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+// PostAudience links a Custom post to one of the lists it targets
+type PostAudience struct {
+	bun.BaseModel `bun:"post_audiences"`
+	PostId        string `json:"postId" bun:"postId,type:varchar(36),pk,notnull"`
+	ListId        string `json:"listId" bun:"listId,type:varchar(36),pk,notnull"`
+}
+
+type AudienceListPost struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+type AudienceMembersPost struct {
+	UserIds []string `json:"userIds" validate:"required,min=1,max=100,dive,required"`
+}
+
+type AudienceMemberInfo struct {
+	UserId    string `json:"userId" bun:"userId"`
+	ProfileId string `json:"profileId" bun:"profileId"`
+	FirstName string `json:"firstname" bun:"firstname"`
+	Lastname  string `json:"lastname" bun:"lastname"`
+	Avatar    string `json:"avatar" bun:"avatarUrl"`
+}
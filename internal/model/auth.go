@@ -6,12 +6,21 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// Role grants an account moderation rights on top of a regular user's
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+)
+
 type User struct {
 	bun.BaseModel `bun:"accounts"`
 	UserUuid      string    `json:"id" bun:"id,type:varchar(36),pk,notnull"`
 	Username      string    `json:"username" bun:"username,type:varchar(50),notnull"`
 	Salt          string    `json:"salt" bun:"salt,type:varchar(64),notnull"`
 	Hash          string    `json:"hashPassword" bun:"hashPassword,type:varchar(255),notnull"`
+	Role          Role      `json:"role" bun:"role,type:varchar(20),notnull"`
 	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
 	UpdatedAt     time.Time `json:"updatedAt" bun:"updatedAt,type:timestamp,nullzero"`
 	Deleted       int       `json:"deleted" bun:"deleted,type:tinyint,notnull"`
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Collection of bookmarks saved without a name
+const DefaultCollection = "saved"
+
+// Bookmark is a post saved by a user. A post is saved at most once per user,
+// in a single collection.
+type Bookmark struct {
+	bun.BaseModel `bun:"bookmarks"`
+	BookmarkId    string    `json:"bookmarkId" bun:"bookmarkId,type:varchar(36),pk,notnull"`
+	UserId        string    `json:"userId" bun:"userId,type:varchar(36),notnull,unique:user_post"`
+	PostId        string    `json:"postId" bun:"postId,type:varchar(36),notnull,unique:user_post"`
+	Collection    string    `json:"collection" bun:"collection,type:varchar(50),notnull"`
+	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+type BookmarkPost struct {
+	Collection string `json:"collection" validate:"omitempty,max=50"`
+}
+
+// BookmarkedPost is a saved post as listed to its saver
+type BookmarkedPost struct {
+	NewsFeed
+	Collection string    `json:"collection"`
+	SavedAt    time.Time `json:"savedAt"`
+}
+
+type BookmarkCollection struct {
+	Name  string `json:"name" bun:"collection"`
+	Count int    `json:"count" bun:"count"`
+}
+
+// BookmarkPage is one page of bookmarks, NextCursor is empty on the last page
+type BookmarkPage struct {
+	Results    []BookmarkedPost `json:"results"`
+	NextCursor string           `json:"nextCursor"`
+}
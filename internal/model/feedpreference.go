@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// HideReason tells why a viewer hid a post from their newsfeed
+type HideReason string
+
+const (
+	HiddenReason        HideReason = "hidden"
+	NotInterestedReason HideReason = "not_interested"
+)
+
+// HiddenPost is a post a viewer no longer wants in their newsfeed. It is still
+// shown everywhere else, e.g. on its author's profile.
+type HiddenPost struct {
+	bun.BaseModel `bun:"hidden_posts"`
+	UserId        string     `json:"userId" bun:"userId,type:varchar(36),pk,notnull"`
+	PostId        string     `json:"postId" bun:"postId,type:varchar(36),pk,notnull"`
+	Reason        HideReason `json:"reason" bun:"reason,type:varchar(20),notnull"`
+	CreatedAt     time.Time  `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+// SnoozedAuthor keeps the posts of an author out of a viewer's newsfeed until
+// Until, without unfollowing them.
+type SnoozedAuthor struct {
+	bun.BaseModel `bun:"snoozed_authors"`
+	UserId        string    `json:"userId" bun:"userId,type:varchar(36),pk,notnull"`
+	AuthorId      string    `json:"authorId" bun:"authorId,type:varchar(36),pk,notnull"`
+	Until         time.Time `json:"until" bun:"until,type:timestamp,notnull"`
+	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+type HidePost struct {
+	Reason HideReason `json:"reason" validate:"omitempty,oneof=hidden not_interested"`
+}
+
+type HiddenPostInfo struct {
+	PostId    string     `json:"postId" bun:"postId"`
+	Content   string     `json:"content" bun:"content"`
+	Reason    HideReason `json:"reason" bun:"reason"`
+	HiddenAt  time.Time  `json:"hiddenAt" bun:"hiddenAt"`
+	AuthorId  string     `json:"authorId" bun:"authorId"`
+	FirstName string     `json:"firstname" bun:"firstname"`
+	Lastname  string     `json:"lastname" bun:"lastname"`
+	Avatar    string     `json:"avatar" bun:"avatarUrl"`
+}
+
+type SnoozedAuthorInfo struct {
+	AuthorId  string    `json:"authorId" bun:"authorId"`
+	ProfileId string    `json:"profileId" bun:"profileId"`
+	FirstName string    `json:"firstname" bun:"firstname"`
+	Lastname  string    `json:"lastname" bun:"lastname"`
+	Avatar    string    `json:"avatar" bun:"avatarUrl"`
+	Until     time.Time `json:"until" bun:"until"`
+}
+
+// FeedPreferences lists what a viewer keeps out of their newsfeed, so that
+// each entry can be undone.
+type FeedPreferences struct {
+	HiddenPosts    []HiddenPostInfo    `json:"hiddenPosts"`
+	SnoozedAuthors []SnoozedAuthorInfo `json:"snoozedAuthors"`
+}
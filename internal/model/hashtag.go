@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Hashtag struct {
+	bun.BaseModel `bun:"hashtags"`
+	HashtagId     int64     `json:"id" bun:"id,pk,autoincrement"`
+	Tag           string    `json:"tag" bun:"tag,type:varchar(100),unique,notnull"`
+	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+type PostHashtag struct {
+	bun.BaseModel `bun:"post_hashtags"`
+	PostId        string `json:"postId" bun:"postId,type:varchar(36),pk,notnull"`
+	HashtagId     int64  `json:"hashtagId" bun:"hashtagId,pk,notnull"`
+}
+
+type TrendingTag struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+}
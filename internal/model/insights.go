@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Impression is a post shown on a viewer's screen, DwellMs is how long it
+// stayed there.
+type Impression struct {
+	PostId  string `json:"postId" validate:"required,uuid"`
+	DwellMs int64  `json:"dwellMs" validate:"min=0,max=3600000"`
+}
+
+// ImpressionBatch is sent by clients every few seconds with the posts shown
+// since the previous batch.
+type ImpressionBatch struct {
+	Impressions []Impression `json:"impressions" validate:"required,min=1,max=200,dive"`
+}
+
+// ImpressionTarget is a post a viewer reported and is allowed to see,
+// Follower tells whether the viewer follows its author.
+type ImpressionTarget struct {
+	PostId   string `bun:"postId"`
+	AuthorId string `bun:"authorId"`
+	Follower bool   `bun:"follower"`
+}
+
+// PostDailyStats is the daily rollup of the impressions of a post. A viewer
+// makes at most one impression of a post per day, counted as a follower
+// impression when they followed the author.
+type PostDailyStats struct {
+	bun.BaseModel          `bun:"post_daily_stats,alias:pds"`
+	PostId                 string    `json:"-" bun:"postId,type:varchar(36),pk,notnull"`
+	Day                    time.Time `json:"day" bun:"day,type:date,pk,notnull"`
+	Impressions            int64     `json:"impressions" bun:"impressions,type:int,notnull"`
+	FollowerImpressions    int64     `json:"followerImpressions" bun:"followerImpressions,type:int,notnull"`
+	NonFollowerImpressions int64     `json:"nonFollowerImpressions" bun:"nonFollowerImpressions,type:int,notnull"`
+	DwellMs                int64     `json:"dwellMs" bun:"dwellMs,type:bigint,notnull"`
+}
+
+// PostReach counts the distinct viewers of a post over its lifetime. A viewer
+// who saw the post both before and after following its author is in both
+// splits but counted once in Reach.
+type PostReach struct {
+	Reach            int64 `json:"reach"`
+	FollowerReach    int64 `json:"followerReach"`
+	NonFollowerReach int64 `json:"nonFollowerReach"`
+}
+
+// PostInsights sums the daily rollups of a post over its lifetime, its reach
+// comes from PostReach. Engagements are its reactions, comments and shares,
+// EngagementRate relates them to the reach.
+type PostInsights struct {
+	PostId           string           `json:"postId" bun:"postId"`
+	CreatedAt        time.Time        `json:"createdAt" bun:"createdAt"`
+	Impressions      int64            `json:"impressions" bun:"impressions"`
+	Reach            int64            `json:"reach" bun:"-"`
+	FollowerReach    int64            `json:"followerReach" bun:"-"`
+	NonFollowerReach int64            `json:"nonFollowerReach" bun:"-"`
+	Engagements      int64            `json:"engagements" bun:"engagements"`
+	EngagementRate   float64          `json:"engagementRate" bun:"-"`
+	DwellMs          int64            `json:"-" bun:"dwellMs"`
+	AvgDwellMs       int64            `json:"avgDwellMs" bun:"-"`
+	Daily            []PostDailyStats `json:"daily,omitempty" bun:"-"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// LinkPreview is the OpenGraph card of the first link of a post
+type LinkPreview struct {
+	bun.BaseModel `bun:"link_previews"`
+	PostId        string    `json:"-" bun:"postId,type:varchar(36),pk,notnull"`
+	Url           string    `json:"url" bun:"url,type:varchar(2048),notnull"`
+	Title         string    `json:"title" bun:"title,type:varchar(300)"`
+	Description   string    `json:"description" bun:"description,type:text"`
+	ImageUrl      string    `json:"imageUrl" bun:"imageUrl,type:varchar(2048)"`
+	SiteName      string    `json:"siteName" bun:"siteName,type:varchar(200)"`
+	FetchedAt     time.Time `json:"fetchedAt" bun:"fetchedAt,type:timestamp,notnull,nullzero"`
+}
+
+// Empty tells whether nothing worth showing was found at the link
+func (p *LinkPreview) Empty() bool {
+	return p.Title == "" && p.Description == "" && p.ImageUrl == ""
+}
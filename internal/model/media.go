@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PostMedia is an uploaded file. It belongs to its uploader until it is
+// attached to a post, Position orders the media of a post.
+type PostMedia struct {
+	bun.BaseModel `bun:"post_media"`
+	MediaId       string    `json:"id" bun:"mediaId,type:varchar(36),pk,notnull"`
+	UserId        string    `json:"-" bun:"userId,type:varchar(36),notnull"`
+	PostId        *string   `json:"-" bun:"postId,type:varchar(36)"`
+	Url           string    `json:"url" bun:"url,type:varchar(255),notnull"`
+	MimeType      string    `json:"mimeType" bun:"mimeType,type:varchar(100),notnull"`
+	Width         int       `json:"width" bun:"width,type:int"`
+	Height        int       `json:"height" bun:"height,type:int"`
+	SizeBytes     int64     `json:"sizeBytes" bun:"sizeBytes,type:bigint"`
+	AltText       string    `json:"altText" bun:"altText,type:varchar(1000)"`
+	Position      int       `json:"position" bun:"position,type:int"`
+	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type MentionTarget string
+
+const (
+	MentionPost    MentionTarget = "post"
+	MentionComment MentionTarget = "comment"
+)
+
+// Mention is a resolved @username inside a post or comment content. Offset
+// and Length count unicode code points and cover the leading @.
+type Mention struct {
+	bun.BaseModel `bun:"mentions"`
+	MentionId     string        `json:"-" bun:"mentionId,type:varchar(36),pk,notnull"`
+	TargetType    MentionTarget `json:"-" bun:"targetType,type:varchar(20),notnull"`
+	TargetId      string        `json:"-" bun:"targetId,type:varchar(36),notnull"`
+	UserId        string        `json:"userId" bun:"userId,type:varchar(36),notnull"`
+	Username      string        `json:"username" bun:"username,type:varchar(50),notnull"`
+	Offset        int           `json:"offset" bun:"offset,type:int,notnull"`
+	Length        int           `json:"length" bun:"length,type:int,notnull"`
+	CreatedAt     time.Time     `json:"-" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Migration is a schema change shipped with the code that relies on it.
+// Versions sort in the order the migrations are applied.
+type Migration struct {
+	Version     string
+	Description string
+	Statements  []string
+}
+
+// SchemaMigration records an applied migration
+type SchemaMigration struct {
+	bun.BaseModel `bun:"schema_migrations"`
+	Version       string    `json:"version" bun:"version,type:varchar(100),pk,notnull"`
+	AppliedAt     time.Time `json:"appliedAt" bun:"appliedAt,type:timestamp,notnull,nullzero"`
+}
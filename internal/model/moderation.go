@@ -0,0 +1,127 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type ReportTarget string
+
+const (
+	ReportedPost    ReportTarget = "post"
+	ReportedComment ReportTarget = "comment"
+	ReportedProfile ReportTarget = "profile"
+)
+
+type ReportReason string
+
+const (
+	ReasonSpam           ReportReason = "spam"
+	ReasonHarassment     ReportReason = "harassment"
+	ReasonHateSpeech     ReportReason = "hate_speech"
+	ReasonViolence       ReportReason = "violence"
+	ReasonNudity         ReportReason = "nudity"
+	ReasonMisinformation ReportReason = "misinformation"
+	ReasonSelfHarm       ReportReason = "self_harm"
+	ReasonImpersonation  ReportReason = "impersonation"
+	ReasonOther          ReportReason = "other"
+)
+
+type ReportStatus string
+
+const (
+	OpenReport      ReportStatus = "open"
+	ResolvedReport  ReportStatus = "resolved"
+	DismissedReport ReportStatus = "dismissed"
+)
+
+// Report is one user flagging a post, a comment or a profile. A user reports
+// a given target at most once.
+type Report struct {
+	bun.BaseModel `bun:"reports"`
+	ReportId      string       `json:"reportId" bun:"reportId,type:varchar(36),pk,notnull"`
+	ReporterId    string       `json:"reporterId" bun:"reporterId,type:varchar(36),notnull,unique:reporter_target"`
+	TargetType    ReportTarget `json:"targetType" bun:"targetType,type:varchar(20),notnull,unique:reporter_target"`
+	TargetId      string       `json:"targetId" bun:"targetId,type:varchar(36),notnull,unique:reporter_target"`
+	Reason        ReportReason `json:"reason" bun:"reason,type:varchar(30),notnull"`
+	Details       string       `json:"details" bun:"details,type:varchar(500)"`
+	Status        ReportStatus `json:"status" bun:"status,type:varchar(20),notnull,default:'open'"`
+	ResolvedBy    *string      `json:"resolvedBy,omitempty" bun:"resolvedBy,type:varchar(36)"`
+	ResolvedAt    *time.Time   `json:"resolvedAt,omitempty" bun:"resolvedAt,type:timestamp"`
+	CreatedAt     time.Time    `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+type ReportPost struct {
+	TargetType ReportTarget `json:"targetType" validate:"required,oneof=post comment profile"`
+	TargetId   string       `json:"targetId" validate:"required,uuid"`
+	Reason     ReportReason `json:"reason" validate:"required,oneof=spam harassment hate_speech violence nudity misinformation self_harm impersonation other"`
+	Details    string       `json:"details" validate:"max=500"`
+}
+
+// ModerationQueueItem groups the reports of one target
+type ModerationQueueItem struct {
+	TargetType      ReportTarget   `json:"targetType" bun:"targetType"`
+	TargetId        string         `json:"targetId" bun:"targetId"`
+	ReportCount     int            `json:"reportCount" bun:"reportCount"`
+	Reasons         []ReportReason `json:"reasons" bun:"-"`
+	ReasonList      string         `json:"-" bun:"reasons"`
+	FirstReportedAt time.Time      `json:"firstReportedAt" bun:"firstReportedAt"`
+	LastReportedAt  time.Time      `json:"lastReportedAt" bun:"lastReportedAt"`
+}
+
+type ModerationActionType string
+
+const (
+	DismissAction   ModerationActionType = "dismiss"
+	HideAction      ModerationActionType = "hide"
+	RestoreAction   ModerationActionType = "restore"
+	WarnAction      ModerationActionType = "warn"
+	SuspendAction   ModerationActionType = "suspend"
+	UnsuspendAction ModerationActionType = "unsuspend"
+	// AutoHideAction is taken by the system once enough users reported a target
+	AutoHideAction ModerationActionType = "auto_hide"
+)
+
+// SystemModerator is the moderatorId of the actions nobody took by hand
+const SystemModerator = "system"
+
+// ModerationAction is the audit log of moderation decisions. Rows are never
+// updated or deleted.
+type ModerationAction struct {
+	bun.BaseModel `bun:"moderation_actions"`
+	ActionId      string               `json:"actionId" bun:"actionId,type:varchar(36),pk,notnull"`
+	ModeratorId   string               `json:"moderatorId" bun:"moderatorId,type:varchar(36),notnull"`
+	Action        ModerationActionType `json:"action" bun:"action,type:varchar(20),notnull"`
+	TargetType    ReportTarget         `json:"targetType" bun:"targetType,type:varchar(20),notnull"`
+	TargetId      string               `json:"targetId" bun:"targetId,type:varchar(36),notnull"`
+	SubjectId     string               `json:"subjectId" bun:"subjectId,type:varchar(36),notnull"`
+	Note          string               `json:"note" bun:"note,type:varchar(500)"`
+	ExpiresAt     *time.Time           `json:"expiresAt,omitempty" bun:"expiresAt,type:timestamp"`
+	CreatedAt     time.Time            `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+// Suspension locks a user out until Until. It is the source of truth for the
+// suspension keys cached in Redis.
+type Suspension struct {
+	bun.BaseModel `bun:"suspensions"`
+	UserId        string    `json:"userId" bun:"userId,type:varchar(36),pk,notnull"`
+	ActionId      string    `json:"actionId" bun:"actionId,type:varchar(36),notnull"`
+	Until         time.Time `json:"until" bun:"until,type:timestamp,notnull"`
+	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+// ModerationDecision is a moderator's answer to the reports of a target.
+// Days is the length of a suspension.
+type ModerationDecision struct {
+	Action ModerationActionType `json:"action" validate:"required,oneof=dismiss hide restore warn suspend unsuspend"`
+	Note   string               `json:"note" validate:"max=500"`
+	Days   int                  `json:"days" validate:"omitempty,min=1,max=365"`
+}
+
+type ModerationActionFilter struct {
+	TargetType  ReportTarget
+	TargetId    string
+	ModeratorId string
+	SubjectId   string
+}
@@ -12,6 +12,8 @@ const (
 	Public  Privacy = "public"
 	Private Privacy = "private"
 	Friends Privacy = "friends"
+	// Custom posts are shown to the members of the audience lists they target
+	Custom Privacy = "custom"
 )
 
 type LikeType string
@@ -21,6 +23,31 @@ const (
 	LikeComment LikeType = "comment"
 )
 
+// Reaction is the kind of a like. Comments only ever get ReactionLike.
+type Reaction string
+
+const (
+	ReactionLike  Reaction = "like"
+	ReactionLove  Reaction = "love"
+	ReactionHaha  Reaction = "haha"
+	ReactionWow   Reaction = "wow"
+	ReactionSad   Reaction = "sad"
+	ReactionAngry Reaction = "angry"
+)
+
+var Reactions = []Reaction{ReactionLike, ReactionLove, ReactionHaha, ReactionWow, ReactionSad, ReactionAngry}
+
+// ReactionCounts are the per-reaction counters of a post, likeCount stays
+// their total.
+type ReactionCounts struct {
+	Like  int64 `json:"like" bun:"reactionLike,type:int"`
+	Love  int64 `json:"love" bun:"reactionLove,type:int"`
+	Haha  int64 `json:"haha" bun:"reactionHaha,type:int"`
+	Wow   int64 `json:"wow" bun:"reactionWow,type:int"`
+	Sad   int64 `json:"sad" bun:"reactionSad,type:int"`
+	Angry int64 `json:"angry" bun:"reactionAngry,type:int"`
+}
+
 type CommentStatus string
 
 const (
@@ -29,56 +56,163 @@ const (
 	DeletedComment CommentStatus = "deleted"
 )
 
+// PostStatus tells whether a post is live. Drafts and scheduled posts are
+// only visible to their author until published.
+type PostStatus string
+
+const (
+	PublishedPost PostStatus = "published"
+	DraftPost     PostStatus = "draft"
+	ScheduledPost PostStatus = "scheduled"
+)
+
+type PostType string
+
+const (
+	TextPostType PostType = "text"
+	PollPostType PostType = "poll"
+)
+
+type FeedMode string
+
+const (
+	LatestFeed FeedMode = "latest"
+	RankedFeed FeedMode = "ranked"
+)
+
+// FeedRange limits a feed request to the posts created in [Since, Until),
+// a zero time leaves that side open.
+type FeedRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+func (r FeedRange) IsZero() bool {
+	return r.Since.IsZero() && r.Until.IsZero()
+}
+
+// FeedPage is one page of the newsfeed. NextCursor is set once the timeline
+// runs out and leads to the older posts, it is empty at the end of the feed.
+type FeedPage struct {
+	Results    []NewsFeed `json:"results"`
+	NextCursor string     `json:"nextCursor"`
+}
+
 type Post struct {
 	bun.BaseModel `bun:"posts"`
-	PostId        string    `json:"id" bun:"postId,type:varchar(36),pk,notnull"`
-	UserId        string    `json:"userId" bun:"userId,type:varchar(36),notnull"`
-	Content       string    `json:"content" bun:"content,type:text,notnull"`
-	Privacy       Privacy   `json:"privacy" bun:"privacy,type:enum"`
-	LikeCount     int64     `json:"likeCount" bun:"likeCount,type:int"`
-	CommentCount  int64     `json:"commentCount" bun:"commentCount,type:int"`
-	ShareCount    int64     `json:"shareCount" bun:"shareCount,type:int"`
-	Deleted       int       `json:"deleted" bun:"deleted,type:tinyint,notnull"`
-	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
-	UpdatedAt     time.Time `json:"updatedAt" bun:"updatedAt,type:timestamp,nullzero"`
+	PostId        string         `json:"id" bun:"postId,type:varchar(36),pk,notnull"`
+	UserId        string         `json:"userId" bun:"userId,type:varchar(36),notnull"`
+	Content       string         `json:"content" bun:"content,type:text,notnull"`
+	Privacy       Privacy        `json:"privacy" bun:"privacy,type:enum"`
+	Type          PostType       `json:"type" bun:"type,type:varchar(20),notnull,default:'text'"`
+	LikeCount     int64          `json:"likeCount" bun:"likeCount,type:int"`
+	CommentCount  int64          `json:"commentCount" bun:"commentCount,type:int"`
+	ShareCount    int64          `json:"shareCount" bun:"shareCount,type:int"`
+	Reactions     ReactionCounts `json:"reactions" bun:"embed:"`
+	SharedPostId  *string        `json:"sharedPostId,omitempty" bun:"sharedPostId,type:varchar(36)"`
+	Edited        bool           `json:"edited" bun:"edited,type:tinyint,notnull"`
+	Status        PostStatus     `json:"status" bun:"status,type:varchar(20),notnull,default:'published'"`
+	PublishAt     *time.Time     `json:"publishAt,omitempty" bun:"publishAt,type:timestamp"`
+	PinnedAt      *time.Time     `json:"pinnedAt,omitempty" bun:"pinnedAt,type:timestamp"`
+	Deleted       int            `json:"deleted" bun:"deleted,type:tinyint,notnull"`
+	CreatedAt     time.Time      `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+	UpdatedAt     time.Time      `json:"updatedAt" bun:"updatedAt,type:timestamp,nullzero"`
 }
 
+// Post.Deleted is 1 for posts deleted by their author and HiddenByModeration
+// for posts taken down by a moderator, which can still be restored.
+const HiddenByModeration = 2
+
+// NewsfeedPost is the body of a new post. A draft is kept until published,
+// a post with a future PublishAt is published by the scheduler.
 type NewsfeedPost struct {
-	Content string  `json:"content" validate:"required"`
-	Privacy Privacy `json:"privacy" validate:"required"`
+	Content         string     `json:"content" validate:"required"`
+	Privacy         Privacy    `json:"privacy" validate:"required,oneof=public private friends custom"`
+	AudienceListIds []string   `json:"audienceListIds"`
+	MediaIds        []string   `json:"mediaIds"`
+	Draft           bool       `json:"draft"`
+	PublishAt       *time.Time `json:"publishAt"`
+	Poll            *PollPost  `json:"poll"`
+}
+
+// SharePost is the body of a repost, Content is the optional quote
+type SharePost struct {
+	Content string  `json:"content"`
+	Privacy Privacy `json:"privacy" validate:"required,oneof=public private friends"`
+}
+
+// PostPatch edits a post. PublishAt can only be changed before the post is
+// published, scheduling a draft or rescheduling a scheduled post.
+// AudienceListIds replaces the lists of a custom post.
+type PostPatch struct {
+	Content         *string    `json:"content"`
+	Privacy         *Privacy   `json:"privacy" validate:"omitempty,oneof=public private friends custom"`
+	AudienceListIds []string   `json:"audienceListIds"`
+	PublishAt       *time.Time `json:"publishAt"`
 }
 
 type NewsFeed struct {
-	PostId       string    `json:"postId" bun:"postId"`
-	AvatarUrl    string    `json:"avatarUrl" bun:"avatarUrl"`
-	FirstName    string    `json:"firstname" bun:"firstname"`
-	LastName     string    `json:"lastname" bun:"lastname"`
-	Content      string    `json:"content" bun:"content"`
-	Privacy      Privacy   `json:"privacy" bun:"privacy"`
-	LikeCount    int       `json:"likeCount" bun:"likeCount"`
-	CommentCount int       `json:"commentCount" bun:"commentCount"`
-	ShareCount   int       `json:"shareCount" bun:"shareCount"`
-	CreatedAt    time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
-	UpdatedAt    time.Time `json:"updatedAt" bun:"updatedAt,type:timestamp,nullzero"`
-	Liked        bool      `json:"liked" bun:"liked"`
+	PostId       string         `json:"postId" bun:"postId"`
+	UserId       string         `json:"userId" bun:"userId"`
+	AvatarUrl    string         `json:"avatarUrl" bun:"avatarUrl"`
+	FirstName    string         `json:"firstname" bun:"firstname"`
+	LastName     string         `json:"lastname" bun:"lastname"`
+	Content      string         `json:"content" bun:"content"`
+	Privacy      Privacy        `json:"privacy" bun:"privacy"`
+	Type         PostType       `json:"type" bun:"type"`
+	LikeCount    int            `json:"likeCount" bun:"likeCount"`
+	CommentCount int            `json:"commentCount" bun:"commentCount"`
+	ShareCount   int            `json:"shareCount" bun:"shareCount"`
+	Reactions    ReactionCounts `json:"reactions" bun:"embed:"`
+	SharedPostId *string        `json:"sharedPostId,omitempty" bun:"sharedPostId"`
+	CreatedAt    time.Time      `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+	UpdatedAt    time.Time      `json:"updatedAt" bun:"updatedAt,type:timestamp,nullzero"`
+	Edited       bool           `json:"edited" bun:"edited"`
+	Status       PostStatus     `json:"status" bun:"status"`
+	PublishAt    *time.Time     `json:"publishAt,omitempty" bun:"publishAt"`
+	PinnedAt     *time.Time     `json:"-" bun:"pinnedAt"`
+	Pinned       bool           `json:"pinned" bun:"-"`
+	Reaction     Reaction       `json:"reaction" bun:"-"`
+	Bookmarked   bool           `json:"bookmarked" bun:"-"`
+	Poll         *PollState     `json:"poll,omitempty" bun:"-"`
+	SharedPost   *NewsFeed      `json:"sharedPost,omitempty" bun:"-"`
+	Media        []PostMedia    `json:"media" bun:"-"`
+	Mentions     []Mention      `json:"mentions" bun:"-"`
+	LinkPreview  *LinkPreview   `json:"linkPreview,omitempty" bun:"-"`
+}
+
+// TimelineEntry is a post reference stored in a user's Redis timeline,
+// scored by the post creation time.
+type TimelineEntry struct {
+	PostId    string    `json:"postId" bun:"postId"`
+	CreatedAt time.Time `json:"createdAt" bun:"createdAt"`
 }
 
+type AuthorAffinity struct {
+	AuthorId     string `json:"authorId" bun:"authorId"`
+	Interactions int    `json:"interactions" bun:"interactions"`
+}
+
+// Like is a like on a post or a comment, TargetId holds the id of either
+// depending on Type.
 type Like struct {
 	bun.BaseModel `bun:"likes"`
 	LikeId        string    `json:"likeId" bun:"likeId,type:varchar(36),pk,notnull"`
-	PostId        string    `json:"postId" bun:"postId,type:varchar(36),notnull"`
+	TargetId      string    `json:"targetId" bun:"targetId,type:varchar(36),notnull"`
 	UserId        string    `json:"userId" bun:"userId,type:varchar(36),notnull"`
 	Type          LikeType  `json:"type" bun:"type"`
+	Reaction      Reaction  `json:"reaction" bun:"reaction,type:varchar(10),notnull"`
 	IsActive      bool      `json:"isActive" bun:"isActive,default:1"`
 	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
 	UpdatedAt     time.Time `json:"updatedAt" bun:"updatedAt,type:timestamp,nullzero"`
 }
 
 type LikerInfo struct {
-	ProfileId string `json:"profileId" bun:"profileId"`
-	FirstName string `json:"firstname" bun:"firstname"`
-	Lastname  string `json:"lastname" bun:"lastname"`
-	Avatar    string `json:"avatar" bun:"avatarUrl"`
+	ProfileId string   `json:"profileId" bun:"profileId"`
+	FirstName string   `json:"firstname" bun:"firstname"`
+	Lastname  string   `json:"lastname" bun:"lastname"`
+	Avatar    string   `json:"avatar" bun:"avatarUrl"`
+	Reaction  Reaction `json:"reaction" bun:"reaction"`
 }
 
 type Comment struct {
@@ -91,26 +225,49 @@ type Comment struct {
 	ReplyCount    int           `json:"repliesCount" bun:"repliesCount"`
 	Content       string        `json:"content" bun:"content,type:text,notnull"`
 	Status        CommentStatus `json:"status" bun:"status"`
+	Edited        bool          `json:"edited" bun:"edited,type:tinyint,notnull"`
 	CreatedAt     time.Time     `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
 	UpdatedAt     time.Time     `json:"updatedAt" bun:"updatedAt,type:timestamp,nullzero"`
 }
 
 type CommentPost struct {
-	Content string `json:"content"`
+	Content string `json:"content" validate:"required"`
 	Parent  string `json:"parent"`
 }
 
+// CommentInfo is a comment as returned to clients. Replies are only set on
+// top-level comments, with a preview of their first replies.
 type CommentInfo struct {
-	CommentId string    `json:"commentId" bun:"commentId"`
-	ProfileId string    `json:"profileId" bun:"profileId"`
-	FirstName string    `json:"firstname" bun:"firstname"`
-	Lastname  string    `json:"lastname" bun:"lastname"`
-	Avatar    string    `json:"avatar" bun:"avatarUrl"`
-	Content   string    `json:"content" bun:"content"`
-	CreatedAt time.Time `json:"createdAt" bun:"createdAt"`
+	CommentId  string        `json:"commentId" bun:"commentId"`
+	ParentId   *string       `json:"parentId" bun:"parentId"`
+	UserId     string        `json:"userId" bun:"userId"`
+	ProfileId  string        `json:"profileId" bun:"profileId"`
+	FirstName  string        `json:"firstname" bun:"firstname"`
+	Lastname   string        `json:"lastname" bun:"lastname"`
+	Avatar     string        `json:"avatar" bun:"avatarUrl"`
+	Content    string        `json:"content" bun:"content"`
+	LikeCount  int           `json:"likeCount" bun:"likeCount"`
+	ReplyCount int           `json:"repliesCount" bun:"repliesCount"`
+	Status     CommentStatus `json:"status" bun:"status"`
+	Edited     bool          `json:"edited" bun:"edited"`
+	CreatedAt  time.Time     `json:"createdAt" bun:"createdAt"`
+	UpdatedAt  time.Time     `json:"updatedAt" bun:"updatedAt,nullzero"`
+	Liked      bool          `json:"liked" bun:"-"`
+	Mentions   []Mention     `json:"mentions" bun:"-"`
+	Replies    []CommentInfo `json:"replies,omitempty" bun:"-"`
 }
 
 type CommentPut struct {
-	CommentId string `json:"commentId"`
-	Content   string `json:"content"`
+	CommentId string `json:"commentId" validate:"required"`
+	Content   string `json:"content" validate:"required"`
+}
+
+// CounterDrift is a denormalized counter whose stored value differs from the
+// value recomputed from its source table.
+type CounterDrift struct {
+	Table  string `json:"table" bun:"-"`
+	Column string `json:"column" bun:"-"`
+	Id     string `json:"id" bun:"id"`
+	Stored int64  `json:"stored" bun:"stored"`
+	Actual int64  `json:"actual" bun:"actual"`
 }
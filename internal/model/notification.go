@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type NotificationType string
+
+const (
+	MentionInPost    NotificationType = "mention_post"
+	MentionInComment NotificationType = "mention_comment"
+	// ModerationWarning has no actor, it references the reported content
+	ModerationWarning NotificationType = "moderation_warning"
+)
+
+// Notification only references what happened, clients load the target
+// through the regular endpoints, which enforce post privacy.
+type Notification struct {
+	bun.BaseModel  `bun:"notifications"`
+	NotificationId string           `json:"id" bun:"notificationId,type:varchar(36),pk,notnull"`
+	UserId         string           `json:"-" bun:"userId,type:varchar(36),notnull"`
+	ActorId        string           `json:"actorId" bun:"actorId,type:varchar(36)"`
+	Type           NotificationType `json:"type" bun:"type,type:varchar(50),notnull"`
+	PostId         *string          `json:"postId,omitempty" bun:"postId,type:varchar(36)"`
+	CommentId      *string          `json:"commentId,omitempty" bun:"commentId,type:varchar(36)"`
+	IsRead         bool             `json:"isRead" bun:"isRead,default:0"`
+	CreatedAt      time.Time        `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+type NotificationInfo struct {
+	NotificationId string           `json:"id" bun:"notificationId"`
+	ActorId        string           `json:"actorId" bun:"actorId"`
+	FirstName      string           `json:"firstname" bun:"firstname"`
+	LastName       string           `json:"lastname" bun:"lastname"`
+	Avatar         string           `json:"avatar" bun:"avatarUrl"`
+	Type           NotificationType `json:"type" bun:"type"`
+	PostId         *string          `json:"postId,omitempty" bun:"postId"`
+	CommentId      *string          `json:"commentId,omitempty" bun:"commentId"`
+	IsRead         bool             `json:"isRead" bun:"isRead"`
+	CreatedAt      time.Time        `json:"createdAt" bun:"createdAt"`
+}
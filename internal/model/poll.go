@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Poll is attached to a post of type PollPostType, the post content is its
+// question.
+type Poll struct {
+	bun.BaseModel  `bun:"polls"`
+	PostId         string    `json:"postId" bun:"postId,type:varchar(36),pk,notnull"`
+	MultipleChoice bool      `json:"multipleChoice" bun:"multipleChoice,type:tinyint,notnull"`
+	ClosesAt       time.Time `json:"closesAt" bun:"closesAt,type:timestamp,notnull"`
+	CreatedAt      time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+type PollOption struct {
+	bun.BaseModel `bun:"poll_options"`
+	OptionId      string `json:"optionId" bun:"optionId,type:varchar(36),pk,notnull"`
+	PostId        string `json:"postId" bun:"postId,type:varchar(36),notnull"`
+	Position      int    `json:"position" bun:"position,type:int,notnull"`
+	Text          string `json:"text" bun:"text,type:varchar(100),notnull"`
+	VoteCount     int    `json:"voteCount" bun:"voteCount,type:int,notnull"`
+}
+
+// PollVote is one option chosen by a user, a multiple choice vote is stored
+// as one row per option.
+type PollVote struct {
+	bun.BaseModel `bun:"poll_votes"`
+	VoteId        string    `json:"voteId" bun:"voteId,type:varchar(36),pk,notnull"`
+	PostId        string    `json:"postId" bun:"postId,type:varchar(36),notnull"`
+	OptionId      string    `json:"optionId" bun:"optionId,type:varchar(36),notnull,unique:option_user"`
+	UserId        string    `json:"userId" bun:"userId,type:varchar(36),notnull,unique:option_user"`
+	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+// PollPost is the poll part of a new post
+type PollPost struct {
+	Options        []string  `json:"options" validate:"required,min=2,max=10,dive,required,max=100"`
+	ClosesAt       time.Time `json:"closesAt" validate:"required"`
+	MultipleChoice bool      `json:"multipleChoice"`
+}
+
+type PollVotePost struct {
+	OptionIds []string `json:"optionIds" validate:"required,min=1,dive,required"`
+}
+
+// PollState is a poll as seen by one viewer. Counts are only set once the
+// viewer has voted or the poll has closed.
+type PollState struct {
+	MultipleChoice bool              `json:"multipleChoice"`
+	ClosesAt       time.Time         `json:"closesAt"`
+	Closed         bool              `json:"closed"`
+	Voted          bool              `json:"voted"`
+	TotalVotes     *int              `json:"totalVotes,omitempty"`
+	Options        []PollOptionState `json:"options"`
+}
+
+type PollOptionState struct {
+	OptionId  string `json:"optionId"`
+	Text      string `json:"text"`
+	VoteCount *int   `json:"voteCount,omitempty"`
+	Chosen    bool   `json:"chosen"`
+}
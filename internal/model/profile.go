@@ -17,6 +17,7 @@ type UserProfile struct {
 	Address       string    `json:"address,omitempty" bun:"address,type:varchar(255)"`
 	Email         string    `json:"email" bun:"email,type:varchar(150),notnull"`
 	PhoneNumber   string    `json:"phone,omitempty" bun:"phoneNumber,type:varchar(20)"`
+	Private       bool      `json:"private" bun:"private,type:tinyint,notnull"`
 	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
 	UpdatedAt     time.Time `json:"updatedAt" bun:"updatedAt,type:timestamp,nullzero"`
 	UserAuth      *User     `json:"accounts,omitempty" bun:"rel:belongs-to,join:userId=id"`
@@ -30,6 +31,7 @@ type UserProfilePost struct {
 	Address     string `json:"address"`
 	Email       string `json:"email" validate:"required"`
 	PhoneNumber string `json:"phone"`
+	Private     bool   `json:"private"`
 }
 
 type UserProfilePut struct {
@@ -40,4 +42,5 @@ type UserProfilePut struct {
 	Address     string `json:"address"`
 	Email       string `json:"email"`
 	PhoneNumber string `json:"phone"`
+	Private     *bool  `json:"private"`
 }
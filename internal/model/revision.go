@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PostRevision is an immutable snapshot of a post as it was before an edit,
+// CreatedAt being the time of that edit. The current version is the post.
+type PostRevision struct {
+	bun.BaseModel `bun:"post_revisions"`
+	RevisionId    string    `json:"revisionId" bun:"revisionId,type:varchar(36),pk,notnull"`
+	PostId        string    `json:"postId" bun:"postId,type:varchar(36),notnull"`
+	Content       string    `json:"content" bun:"content,type:text,notnull"`
+	Privacy       Privacy   `json:"privacy" bun:"privacy,type:varchar(20),notnull"`
+	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
+
+// CommentRevision is an immutable snapshot of a comment before an edit
+type CommentRevision struct {
+	bun.BaseModel `bun:"comment_revisions"`
+	RevisionId    string    `json:"revisionId" bun:"revisionId,type:varchar(36),pk,notnull"`
+	CommentId     string    `json:"commentId" bun:"commentId,type:varchar(36),notnull"`
+	Content       string    `json:"content" bun:"content,type:text,notnull"`
+	CreatedAt     time.Time `json:"createdAt" bun:"createdAt,type:timestamp,notnull,nullzero"`
+}
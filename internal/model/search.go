@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+type SearchType string
+
+const (
+	SearchPosts  SearchType = "posts"
+	SearchPeople SearchType = "people"
+)
+
+type PersonResult struct {
+	UserId    string `json:"userId" bun:"userId"`
+	Username  string `json:"username" bun:"username"`
+	ProfileId string `json:"profileId" bun:"profileId"`
+	FirstName string `json:"firstname" bun:"firstname"`
+	LastName  string `json:"lastname" bun:"lastname"`
+	Avatar    string `json:"avatar" bun:"avatarUrl"`
+	Highlight string `json:"highlight" bun:"-"`
+
+	Score     float64   `json:"-" bun:"score"`
+	CreatedAt time.Time `json:"-" bun:"createdAt"`
+}
+
+// Key is the position of the person in the results
+func (p *PersonResult) Key() SearchKey {
+	return SearchKey{Score: p.Score, CreatedAt: p.CreatedAt, Id: p.UserId}
+}
+
+// SearchKey is the position of a result in a search: by relevance, then
+// newest first, then by id. A page resumes after the key of the last result
+// of the previous one.
+type SearchKey struct {
+	Score     float64   `bun:"score"`
+	CreatedAt time.Time `bun:"createdAt"`
+	Id        string    `bun:"id"`
+}
+
+type PostResult struct {
+	NewsFeed
+	Highlight string `json:"highlight"`
+}
+
+// SearchDocument is a post or person as fed to a search index
+type SearchDocument struct {
+	Id        string `bun:"id"`
+	Text      string `bun:"text"`
+	Username  string `bun:"username"`
+	CreatedAt int64  `bun:"createdAt"`
+}
+
+// SearchPage is one page of search results, NextCursor is empty on the last page
+type SearchPage struct {
+	Results    any    `json:"results"`
+	NextCursor string `json:"nextCursor"`
+}
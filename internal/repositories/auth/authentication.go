@@ -2,18 +2,26 @@ package authenticationRepo
 
 import (
 	"context"
+	"database/sql"
 	"program/internal/database"
+	"program/internal/model"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
+// activeAccountTTL is how long a user known not to be suspended is cached
+const activeAccountTTL = time.Minute
+
 type AuthenticationRepo struct {
 	rd database.IRedisConnection
 	db database.ISqlConnection
 }
 
-func NewAuthenticationRepo(rd database.IRedisConnection) IAuthenticationRepo {
+func NewAuthenticationRepo(rd database.IRedisConnection, db database.ISqlConnection) IAuthenticationRepo {
 	return &AuthenticationRepo{
 		rd: rd,
+		db: db,
 	}
 }
 
@@ -49,3 +57,48 @@ func (r *AuthenticationRepo) DelRefreshToken(ctx context.Context, key string) er
 	}
 	return nil
 }
+
+// Suspensions are stored in MySQL by moderation, SuspensionKey caches them:
+// "suspended" until the suspension ends, or "active" for activeAccountTTL.
+
+// SuspendUser caches a suspension ending after ttl
+func (r *AuthenticationRepo) SuspendUser(ctx context.Context, userId string, ttl time.Duration) error {
+	_, err := r.rd.GetDB().Set(ctx, SuspensionKey(userId), "suspended", ttl).Result()
+	return err
+}
+
+func (r *AuthenticationRepo) LiftSuspension(ctx context.Context, userId string) error {
+	_, err := r.rd.GetDB().Set(ctx, SuspensionKey(userId), "active", activeAccountTTL).Result()
+	return err
+}
+
+// IsSuspended reads the cached suspension of a user, loading it from MySQL
+// when it is not cached
+func (r *AuthenticationRepo) IsSuspended(ctx context.Context, userId string) (bool, error) {
+	state, err := r.rd.GetDB().Get(ctx, SuspensionKey(userId)).Result()
+	if err == nil {
+		return state == "suspended", nil
+	}
+	if err != redis.Nil {
+		return false, err
+	}
+	suspension := new(model.Suspension)
+	err = r.db.GetDB().NewSelect().
+		Model(suspension).
+		Where("userId = ? AND until > ?", userId, time.Now()).
+		Scan(ctx)
+	// Caching is best effort, the next check reads MySQL again
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.LiftSuspension(ctx, userId)
+			return false, nil
+		}
+		return false, err
+	}
+	r.SuspendUser(ctx, userId, time.Until(suspension.Until))
+	return true, nil
+}
+
+func SuspensionKey(userId string) string {
+	return "suspended:" + userId
+}
@@ -11,4 +11,7 @@ type IAuthenticationRepo interface {
 	AddAccessToBlacklist(ctx context.Context, accessToken string, ttl time.Duration) error
 	IsExisted(ctx context.Context, key string) (bool, error)
 	DelRefreshToken(ctx context.Context, key string) error
+	SuspendUser(ctx context.Context, userId string, ttl time.Duration) error
+	LiftSuspension(ctx context.Context, userId string) error
+	IsSuspended(ctx context.Context, userId string) (bool, error)
 }
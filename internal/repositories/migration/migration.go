@@ -0,0 +1,60 @@
+package migrationRepo
+
+import (
+	"context"
+	"database/sql"
+	"program/internal/database"
+	"program/internal/model"
+	"time"
+)
+
+type MigrationRepo struct {
+	db database.ISqlConnection
+}
+
+func NewMigrationRepo(db database.ISqlConnection) IMigrationRepo {
+	return &MigrationRepo{db: db}
+}
+
+func (r *MigrationRepo) GetMigrations() []model.Migration {
+	return migrations
+}
+
+// GetAppliedVersions creates schema_migrations on first use
+func (r *MigrationRepo) GetAppliedVersions(ctx context.Context) (map[string]bool, error) {
+	_, err := r.db.GetDB().NewCreateTable().
+		Model((*model.SchemaMigration)(nil)).
+		IfNotExists().
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0)
+	err = r.db.GetDB().NewSelect().
+		Model((*model.SchemaMigration)(nil)).
+		Column("version").
+		Scan(ctx, &versions)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	applied := make(map[string]bool, len(versions))
+	for _, version := range versions {
+		applied[version] = true
+	}
+	return applied, nil
+}
+
+// Apply runs the statements of a migration in order and records it. MySQL
+// commits every DDL statement on its own: when one fails, the ones before it
+// stay applied and the migration has to be completed by hand.
+func (r *MigrationRepo) Apply(ctx context.Context, migration model.Migration) error {
+	for _, statement := range migration.Statements {
+		if _, err := r.db.GetDB().ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	_, err := r.db.GetDB().NewInsert().
+		Model(&model.SchemaMigration{Version: migration.Version, AppliedAt: time.Now()}).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,12 @@
+package migrationRepo
+
+import (
+	"context"
+	"program/internal/model"
+)
+
+type IMigrationRepo interface {
+	GetMigrations() []model.Migration
+	GetAppliedVersions(ctx context.Context) (map[string]bool, error)
+	Apply(ctx context.Context, migration model.Migration) error
+}
@@ -0,0 +1,42 @@
+package migrationRepo
+
+import "program/internal/model"
+
+// migrations lists every schema change in the order it is applied. Append
+// new ones, never edit an applied one.
+var migrations = []model.Migration{
+	{
+		Version:     "0001_likes_target",
+		Description: "likes hold post and comment likes: postId becomes targetId, told apart by type",
+		Statements: []string{
+			// Every like was a post like before comments could be liked
+			"UPDATE likes SET type = 'post' WHERE type IS NULL OR type = ''",
+			"ALTER TABLE likes " +
+				"CHANGE COLUMN postId targetId varchar(36) NOT NULL, " +
+				"MODIFY COLUMN type varchar(10) NOT NULL, " +
+				"ADD INDEX likes_target (type, targetId, userId)",
+		},
+	},
+	{
+		Version:     "0002_likes_reaction",
+		Description: "likes carry a reaction, the existing ones are likes",
+		Statements: []string{
+			"ALTER TABLE likes ADD COLUMN reaction varchar(10) NOT NULL DEFAULT 'like'",
+		},
+	},
+	{
+		Version:     "0003_posts_reaction_counts",
+		Description: "posts count each reaction, the existing likes are counted as likes",
+		Statements: []string{
+			"ALTER TABLE posts " +
+				"ADD COLUMN reactionLike int NOT NULL DEFAULT 0, " +
+				"ADD COLUMN reactionLove int NOT NULL DEFAULT 0, " +
+				"ADD COLUMN reactionHaha int NOT NULL DEFAULT 0, " +
+				"ADD COLUMN reactionWow int NOT NULL DEFAULT 0, " +
+				"ADD COLUMN reactionSad int NOT NULL DEFAULT 0, " +
+				"ADD COLUMN reactionAngry int NOT NULL DEFAULT 0",
+			"UPDATE posts p SET p.reactionLike = " +
+				"(SELECT COUNT(*) FROM likes l WHERE l.type = 'post' AND l.targetId = p.postId AND l.isActive = 1)",
+		},
+	},
+}
@@ -0,0 +1,170 @@
+package moderationRepo
+
+import (
+	"context"
+	"database/sql"
+	"program/internal/database"
+	"program/internal/model"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type ModerationRepo struct {
+	db database.ISqlConnection
+}
+
+func NewModerationRepo(db database.ISqlConnection) IModerationRepo {
+	return &ModerationRepo{db: db}
+}
+
+func (r *ModerationRepo) GetDBTx(ctx context.Context) (*bun.Tx, error) {
+	tx, err := r.db.GetDB().BeginTx(ctx, nil)
+	return &tx, err
+}
+
+func (r *ModerationRepo) IsUserExisted(ctx context.Context, userId string) (bool, error) {
+	return r.db.GetDB().NewSelect().
+		Model((*model.User)(nil)).
+		Where("id = ? AND deleted = 0", userId).
+		Exists(ctx)
+}
+
+// CreateReport stores a report and tells whether it is new, a reporter who
+// already reported the target is ignored.
+func (r *ModerationRepo) CreateReport(ctx context.Context, report *model.Report) (bool, error) {
+	resp, err := r.db.GetDB().NewInsert().
+		Model(report).
+		Ignore().
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := resp.RowsAffected()
+	return affected > 0, nil
+}
+
+func (r *ModerationRepo) CountOpenReporters(ctx context.Context, targetType model.ReportTarget, targetId string) (int, error) {
+	return r.db.GetDB().NewSelect().
+		Model((*model.Report)(nil)).
+		Where("targetType = ? AND targetId = ? AND status = ?", targetType, targetId, model.OpenReport).
+		Count(ctx)
+}
+
+// GetModerationQueue groups the reports with the given status by target, the
+// most reported targets first.
+func (r *ModerationRepo) GetModerationQueue(ctx context.Context, status model.ReportStatus, limit, offset int) ([]model.ModerationQueueItem, error) {
+	queue := make([]model.ModerationQueueItem, 0)
+	query := r.db.GetDB().NewSelect().
+		Column("r.targetType", "r.targetId").
+		ColumnExpr("COUNT(*) AS reportCount").
+		ColumnExpr("GROUP_CONCAT(DISTINCT r.reason ORDER BY r.reason) AS reasons").
+		ColumnExpr("MIN(r.createdAt) AS firstReportedAt").
+		ColumnExpr("MAX(r.createdAt) AS lastReportedAt").
+		TableExpr("reports as r").
+		Where("r.status = ?", status).
+		GroupExpr("r.targetType, r.targetId").
+		OrderExpr("reportCount DESC, lastReportedAt DESC")
+	if limit > 0 {
+		query.Limit(limit).Offset(offset)
+	}
+	err := query.Scan(ctx, &queue)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return queue, nil
+		}
+		return nil, err
+	}
+	return queue, nil
+}
+
+func (r *ModerationRepo) GetReports(ctx context.Context, targetType model.ReportTarget, targetId string, limit, offset int) ([]model.Report, error) {
+	reports := make([]model.Report, 0)
+	query := r.db.GetDB().NewSelect().
+		Model(&reports).
+		Where("targetType = ? AND targetId = ?", targetType, targetId).
+		OrderExpr("createdAt DESC")
+	if limit > 0 {
+		query.Limit(limit).Offset(offset)
+	}
+	err := query.Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return reports, nil
+		}
+		return nil, err
+	}
+	return reports, nil
+}
+
+// CloseReportsTransaction resolves or dismisses the open reports of a target
+func (r *ModerationRepo) CloseReportsTransaction(ctx context.Context, tx *bun.Tx, targetType model.ReportTarget, targetId, moderatorId string, status model.ReportStatus) error {
+	_, err := tx.NewUpdate().
+		Model((*model.Report)(nil)).
+		Set("status = ?", status).
+		Set("resolvedBy = ?", moderatorId).
+		Set("resolvedAt = ?", time.Now()).
+		Where("targetType = ? AND targetId = ? AND status = ?", targetType, targetId, model.OpenReport).
+		Exec(ctx)
+	return err
+}
+
+func (r *ModerationRepo) CreateActionTransaction(ctx context.Context, tx *bun.Tx, action *model.ModerationAction) error {
+	_, err := tx.NewInsert().
+		Model(action).
+		Exec(ctx)
+	return err
+}
+
+// GetActions pages through the audit log, newest first. Empty filter fields
+// match everything.
+func (r *ModerationRepo) GetActions(ctx context.Context, filter model.ModerationActionFilter, limit, offset int) ([]model.ModerationAction, error) {
+	actions := make([]model.ModerationAction, 0)
+	query := r.db.GetDB().NewSelect().
+		Model(&actions).
+		OrderExpr("createdAt DESC")
+	if filter.TargetType != "" {
+		query.Where("targetType = ?", filter.TargetType)
+	}
+	if filter.TargetId != "" {
+		query.Where("targetId = ?", filter.TargetId)
+	}
+	if filter.ModeratorId != "" {
+		query.Where("moderatorId = ?", filter.ModeratorId)
+	}
+	if filter.SubjectId != "" {
+		query.Where("subjectId = ?", filter.SubjectId)
+	}
+	if limit > 0 {
+		query.Limit(limit).Offset(offset)
+	}
+	err := query.Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return actions, nil
+		}
+		return nil, err
+	}
+	return actions, nil
+}
+
+// SuspendUserTransaction suspends a user, or moves the end of a running
+// suspension
+func (r *ModerationRepo) SuspendUserTransaction(ctx context.Context, tx *bun.Tx, suspension *model.Suspension) error {
+	_, err := tx.NewInsert().
+		Model(suspension).
+		On("DUPLICATE KEY UPDATE").
+		Set("actionId = VALUES(actionId)").
+		Set("until = VALUES(until)").
+		Set("createdAt = VALUES(createdAt)").
+		Exec(ctx)
+	return err
+}
+
+func (r *ModerationRepo) LiftSuspensionTransaction(ctx context.Context, tx *bun.Tx, userId string) error {
+	_, err := tx.NewDelete().
+		Model((*model.Suspension)(nil)).
+		Where("userId = ?", userId).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,28 @@
+package moderationRepo
+
+import (
+	"context"
+	"program/internal/model"
+
+	"github.com/uptrace/bun"
+)
+
+type IModerationRepo interface {
+	GetDBTx(ctx context.Context) (*bun.Tx, error)
+	IsUserExisted(ctx context.Context, userId string) (bool, error)
+
+	//Reports
+	CreateReport(ctx context.Context, report *model.Report) (bool, error)
+	CountOpenReporters(ctx context.Context, targetType model.ReportTarget, targetId string) (int, error)
+	GetModerationQueue(ctx context.Context, status model.ReportStatus, limit, offset int) ([]model.ModerationQueueItem, error)
+	GetReports(ctx context.Context, targetType model.ReportTarget, targetId string, limit, offset int) ([]model.Report, error)
+	CloseReportsTransaction(ctx context.Context, tx *bun.Tx, targetType model.ReportTarget, targetId, moderatorId string, status model.ReportStatus) error
+
+	//Audit log
+	CreateActionTransaction(ctx context.Context, tx *bun.Tx, action *model.ModerationAction) error
+	GetActions(ctx context.Context, filter model.ModerationActionFilter, limit, offset int) ([]model.ModerationAction, error)
+
+	//Suspensions
+	SuspendUserTransaction(ctx context.Context, tx *bun.Tx, suspension *model.Suspension) error
+	LiftSuspensionTransaction(ctx context.Context, tx *bun.Tx, userId string) error
+}
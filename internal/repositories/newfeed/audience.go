@@ -0,0 +1,36 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"program/internal/model"
+
+	"github.com/uptrace/bun"
+)
+
+// CountOwnedAudienceLists counts how many of listIds belong to userId
+func (r *NewsfeedRepo) CountOwnedAudienceLists(ctx context.Context, userId string, listIds []string) (int, error) {
+	return r.db.GetDB().NewSelect().
+		Model((*model.AudienceList)(nil)).
+		Where("ownerId = ? AND listId IN (?)", userId, bun.In(listIds)).
+		Count(ctx)
+}
+
+// SetPostAudiencesTransaction replaces the audience lists of a post, an empty
+// listIds only clears them.
+func (r *NewsfeedRepo) SetPostAudiencesTransaction(ctx context.Context, tx *bun.Tx, postId string, listIds []string) error {
+	_, err := tx.NewDelete().
+		Model((*model.PostAudience)(nil)).
+		Where("postId = ?", postId).
+		Exec(ctx)
+	if err != nil || len(listIds) == 0 {
+		return err
+	}
+	audiences := make([]model.PostAudience, 0, len(listIds))
+	for _, listId := range listIds {
+		audiences = append(audiences, model.PostAudience{PostId: postId, ListId: listId})
+	}
+	_, err = tx.NewInsert().
+		Model(&audiences).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,107 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"program/internal/model"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// SaveBookmark saves a post, or moves it to another collection when the user
+// already saved it.
+func (r *NewsfeedRepo) SaveBookmark(ctx context.Context, bookmark *model.Bookmark) error {
+	_, err := r.db.GetDB().NewInsert().
+		Model(bookmark).
+		On("DUPLICATE KEY UPDATE").
+		Set("collection = VALUES(collection)").
+		Set("createdAt = VALUES(createdAt)").
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) DeleteBookmark(ctx context.Context, userId, postId string) error {
+	resp, err := r.db.GetDB().NewDelete().
+		Model((*model.Bookmark)(nil)).
+		Where("userId = ? AND postId = ?", userId, postId).
+		Exec(ctx)
+	if err != nil {
+		return err
+	} else if affected, _ := resp.RowsAffected(); affected < 1 {
+		return errors.New("this post is not bookmarked")
+	}
+	return nil
+}
+
+func (r *NewsfeedRepo) GetBookmarkedIds(ctx context.Context, userId string, postIds []string) ([]string, error) {
+	bookmarked := make([]string, 0)
+	if len(postIds) == 0 {
+		return bookmarked, nil
+	}
+	err := r.db.GetDB().NewSelect().
+		Model((*model.Bookmark)(nil)).
+		Column("postId").
+		Where("userId = ? AND postId IN (?)", userId, bun.In(postIds)).
+		Scan(ctx, &bookmarked)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return bookmarked, nil
+		}
+		return nil, err
+	}
+	return bookmarked, nil
+}
+
+// GetBookmarks pages through the bookmarks of a user, newest first, starting
+// after the (createdAt, bookmarkId) position of the previous page. Bookmarks
+// of posts the user can no longer see are skipped. An empty collection
+// lists every collection.
+func (r *NewsfeedRepo) GetBookmarks(ctx context.Context, userId, collection string, afterTime time.Time, afterId string, limit int) ([]model.Bookmark, error) {
+	bookmarks := make([]model.Bookmark, 0)
+	query := r.db.GetDB().NewSelect().
+		Column("b.bookmarkId", "b.userId", "b.postId", "b.collection", "b.createdAt").
+		TableExpr("bookmarks as b").
+		Join("JOIN posts p ON p.postId = b.postId").
+		Where("b.userId = ?", userId).
+		OrderExpr("b.createdAt DESC, b.bookmarkId DESC")
+	if collection != "" {
+		query.Where("b.collection = ?", collection)
+	}
+	if afterId != "" {
+		query.Where("(b.createdAt < ? OR (b.createdAt = ? AND b.bookmarkId < ?))", afterTime, afterTime, afterId)
+	}
+	if limit > 0 {
+		query.Limit(limit)
+	}
+	err := VisibleTo(query, userId).Scan(ctx, &bookmarks)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return bookmarks, nil
+		}
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+// GetBookmarkCollections counts the visible bookmarks of each collection
+func (r *NewsfeedRepo) GetBookmarkCollections(ctx context.Context, userId string) ([]model.BookmarkCollection, error) {
+	collections := make([]model.BookmarkCollection, 0)
+	query := r.db.GetDB().NewSelect().
+		Column("b.collection").
+		ColumnExpr("COUNT(*) AS count").
+		TableExpr("bookmarks as b").
+		Join("JOIN posts p ON p.postId = b.postId").
+		Where("b.userId = ?", userId).
+		GroupExpr("b.collection").
+		OrderExpr("b.collection ASC")
+	err := VisibleTo(query, userId).Scan(ctx, &collections)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return collections, nil
+		}
+		return nil, err
+	}
+	return collections, nil
+}
@@ -0,0 +1,173 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"program/internal/model"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+var commentColumns = []string{
+	"c.commentId",
+	"c.parentId",
+	"c.userId",
+	"pf.profileId",
+	"pf.firstname",
+	"pf.lastname",
+	"pf.avatarUrl",
+	"c.content",
+	"c.likeCount",
+	"c.repliesCount",
+	"c.status",
+	"c.edited",
+	"c.createdAt",
+	"c.updatedAt",
+}
+
+// visibleComments restricts a query on comments aliased as c to the active
+// ones, plus the hidden ones shown to their author or to viewers allowed to
+// see hidden comments. With keepThreads, comments with replies are always
+// kept so that their thread can hang off a placeholder.
+func visibleComments(query *bun.SelectQuery, viewerId string, showHidden, keepThreads bool) *bun.SelectQuery {
+	return query.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+		q.Where("c.status = ?", model.ActiveComment).
+			WhereOr("c.status = ? AND (c.userId = ? OR ?)", model.HiddenComment, viewerId, showHidden)
+		if keepThreads {
+			q.WhereOr("c.repliesCount > 0")
+		}
+		return q
+	})
+}
+
+func (r *NewsfeedRepo) GetComment(ctx context.Context, commentId string) (*model.Comment, error) {
+	comment := new(model.Comment)
+	err := r.db.GetDB().NewSelect().
+		Model(comment).
+		Where("commentId = ?", commentId).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r *NewsfeedRepo) IncreaseReplyCount(ctx context.Context, tx *bun.Tx, commentId string) error {
+	_, err := tx.NewUpdate().Model((*model.Comment)(nil)).
+		Set("repliesCount = repliesCount + 1").
+		Where("commentId = ?", commentId).
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) DecreaseReplyCount(ctx context.Context, tx *bun.Tx, commentId string) error {
+	_, err := tx.NewUpdate().Model((*model.Comment)(nil)).
+		Set("repliesCount = repliesCount - 1").
+		Where("commentId = ? AND repliesCount > 0", commentId).
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) SetCommentStatus(ctx context.Context, tx *bun.Tx, commentId string, status model.CommentStatus) error {
+	resp, err := tx.NewUpdate().Model((*model.Comment)(nil)).
+		Set("status = ?", status).
+		Set("updatedAt = ?", time.Now()).
+		Where("commentId = ?", commentId).
+		Exec(ctx)
+	if err != nil {
+		return err
+	} else if affected, _ := resp.RowsAffected(); affected < 1 {
+		return errors.New("update comment status failed")
+	}
+	return nil
+}
+
+func (r *NewsfeedRepo) IncreaseCommentLikeCount(ctx context.Context, tx *bun.Tx, commentId string) error {
+	_, err := tx.NewUpdate().Model((*model.Comment)(nil)).
+		Set("likeCount = likeCount + 1").
+		Where("commentId = ?", commentId).
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) DecreaseCommentLikeCount(ctx context.Context, tx *bun.Tx, commentId string) error {
+	_, err := tx.NewUpdate().Model((*model.Comment)(nil)).
+		Set("likeCount = likeCount - 1").
+		Where("commentId = ? AND likeCount > 0", commentId).
+		Exec(ctx)
+	return err
+}
+
+// GetReplies pages through the replies of one thread, oldest first
+func (r *NewsfeedRepo) GetReplies(ctx context.Context, limit, offset int, postId, parentId, viewerId string, showHidden bool) (*[]model.CommentInfo, error) {
+	replies := new([]model.CommentInfo)
+	query := r.db.GetDB().NewSelect().
+		Column(commentColumns...).
+		TableExpr("comments as c").
+		Join("JOIN profiles pf ON pf.userId = c.userId").
+		Where("c.postId = ? AND c.parentId = ?", postId, parentId).
+		OrderExpr("c.createdAt ASC")
+	visibleComments(query, viewerId, showHidden, false)
+	if limit > 0 {
+		query.Limit(limit).Offset(offset)
+	}
+	err := query.Scan(ctx, replies)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return replies, nil
+		}
+		return nil, err
+	}
+	return replies, nil
+}
+
+// GetReplyPreviews returns the first perParent replies of each parent comment
+// in a single query.
+func (r *NewsfeedRepo) GetReplyPreviews(ctx context.Context, parentIds []string, perParent int, viewerId string, showHidden bool) ([]model.CommentInfo, error) {
+	replies := make([]model.CommentInfo, 0)
+	if len(parentIds) == 0 || perParent <= 0 {
+		return replies, nil
+	}
+	ranked := r.db.GetDB().NewSelect().
+		Column(commentColumns...).
+		ColumnExpr("ROW_NUMBER() OVER (PARTITION BY c.parentId ORDER BY c.createdAt ASC) AS rowNumber").
+		TableExpr("comments as c").
+		Join("JOIN profiles pf ON pf.userId = c.userId").
+		Where("c.parentId IN (?)", bun.In(parentIds))
+	visibleComments(ranked, viewerId, showHidden, false)
+	query := r.db.GetDB().NewSelect().
+		TableExpr("(?) AS t", ranked).
+		Where("t.rowNumber <= ?", perParent).
+		OrderExpr("t.createdAt ASC")
+	for _, column := range commentColumns {
+		query.Column("t." + column[strings.Index(column, ".")+1:])
+	}
+	err := query.Scan(ctx, &replies)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return replies, nil
+		}
+		return nil, err
+	}
+	return replies, nil
+}
+
+// GetRole returns the role of an account, moderators may act on any content
+func (r *NewsfeedRepo) GetRole(ctx context.Context, userId string) (model.Role, error) {
+	var role model.Role
+	err := r.db.GetDB().NewSelect().
+		Model((*model.User)(nil)).
+		Column("role").
+		Where("id = ? AND deleted = 0", userId).
+		Scan(ctx, &role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.RoleUser, nil
+		}
+		return "", err
+	}
+	return role, nil
+}
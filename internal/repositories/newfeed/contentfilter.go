@@ -0,0 +1,31 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GetFilterCounter returns the count of a content filter in its current
+// window, 0 once the window is over.
+func (r *NewsfeedRepo) GetFilterCounter(ctx context.Context, key string) (int64, error) {
+	count, err := r.rdb.GetDB().Get(ctx, "contentfilter:"+key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+// IncrementFilterCounter counts an event of a content filter in a fixed
+// window that starts with the first event, and returns the count so far.
+func (r *NewsfeedRepo) IncrementFilterCounter(ctx context.Context, key string, window time.Duration) (int64, error) {
+	key = "contentfilter:" + key
+	pipe := r.rdb.GetDB().TxPipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
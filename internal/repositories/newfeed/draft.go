@@ -0,0 +1,90 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"program/internal/model"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// GetDrafts returns the unpublished posts of a user, scheduled ones first in
+// publishing order, then drafts by last edit.
+func (r *NewsfeedRepo) GetDrafts(ctx context.Context, userId string, limit, offset int) (*[]model.NewsFeed, error) {
+	drafts := new([]model.NewsFeed)
+	query := r.db.GetDB().NewSelect().
+		Column(newsfeedColumns...).
+		TableExpr("posts as p").
+		Join("JOIN profiles pf ON pf.userId = p.userId").
+		Where("p.userId = ? AND p.deleted = 0 AND p.status IN (?)", userId, bun.In([]model.PostStatus{model.DraftPost, model.ScheduledPost})).
+		OrderExpr("p.publishAt IS NULL, p.publishAt ASC, COALESCE(p.updatedAt, p.createdAt) DESC")
+	if limit > 0 {
+		query.Limit(limit).Offset(offset)
+	}
+	err := query.Scan(ctx, drafts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return drafts, nil
+		}
+		return nil, err
+	}
+	return drafts, nil
+}
+
+// GetDuePosts returns the scheduled posts whose publish time has passed,
+// oldest first.
+func (r *NewsfeedRepo) GetDuePosts(ctx context.Context, now time.Time, limit int) ([]model.Post, error) {
+	posts := make([]model.Post, 0)
+	query := r.db.GetDB().NewSelect().
+		Model(&posts).
+		Where("status = ? AND deleted = 0 AND publishAt <= ?", model.ScheduledPost, now).
+		OrderExpr("publishAt ASC")
+	if limit > 0 {
+		query.Limit(limit)
+	}
+	err := query.Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return posts, nil
+		}
+		return nil, err
+	}
+	return posts, nil
+}
+
+// PublishPostTransaction makes an unpublished post live as of publishedAt.
+// Only one caller can publish a post, the others get an error, so the
+// scheduler and a manual publish never both fan it out.
+func (r *NewsfeedRepo) PublishPostTransaction(ctx context.Context, tx *bun.Tx, postId string, publishedAt time.Time) error {
+	resp, err := tx.NewUpdate().
+		Model((*model.Post)(nil)).
+		Set("status = ?", model.PublishedPost).
+		Set("publishAt = NULL").
+		Set("createdAt = ?", publishedAt).
+		Where("postId = ? AND deleted = 0 AND status IN (?)", postId, bun.In([]model.PostStatus{model.DraftPost, model.ScheduledPost})).
+		Exec(ctx)
+	if err != nil {
+		return err
+	} else if affected, _ := resp.RowsAffected(); affected < 1 {
+		return errors.New("post is already published")
+	}
+	return nil
+}
+
+// UnschedulePost turns a scheduled post back into a draft
+func (r *NewsfeedRepo) UnschedulePost(ctx context.Context, postId string) error {
+	resp, err := r.db.GetDB().NewUpdate().
+		Model((*model.Post)(nil)).
+		Set("status = ?", model.DraftPost).
+		Set("publishAt = NULL").
+		Where("postId = ? AND deleted = 0 AND status = ?", postId, model.ScheduledPost).
+		Exec(ctx)
+	if err != nil {
+		return err
+	} else if affected, _ := resp.RowsAffected(); affected < 1 {
+		return errors.New("post is not scheduled")
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"program/internal/model"
+	"time"
+)
+
+// notMuted drops the posts a viewer hid or whose author they snoozed. It
+// takes the viewer id twice.
+const notMuted = "NOT EXISTS (SELECT 1 FROM hidden_posts hp WHERE hp.userId = ? AND hp.postId = p.postId) AND NOT EXISTS (SELECT 1 FROM snoozed_authors sa WHERE sa.userId = ? AND sa.authorId = p.userId AND sa.until > NOW())"
+
+// HidePost hides a post, or updates the reason when it is already hidden
+func (r *NewsfeedRepo) HidePost(ctx context.Context, hidden *model.HiddenPost) error {
+	_, err := r.db.GetDB().NewInsert().
+		Model(hidden).
+		On("DUPLICATE KEY UPDATE").
+		Set("reason = VALUES(reason)").
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) UnhidePost(ctx context.Context, userId, postId string) error {
+	resp, err := r.db.GetDB().NewDelete().
+		Model((*model.HiddenPost)(nil)).
+		Where("userId = ? AND postId = ?", userId, postId).
+		Exec(ctx)
+	if err != nil {
+		return err
+	} else if affected, _ := resp.RowsAffected(); affected < 1 {
+		return errors.New("this post is not hidden")
+	}
+	return nil
+}
+
+// SnoozeAuthor snoozes an author, or extends the snooze when it is running
+func (r *NewsfeedRepo) SnoozeAuthor(ctx context.Context, snooze *model.SnoozedAuthor) error {
+	_, err := r.db.GetDB().NewInsert().
+		Model(snooze).
+		On("DUPLICATE KEY UPDATE").
+		Set("until = VALUES(until)").
+		Set("createdAt = VALUES(createdAt)").
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) UnsnoozeAuthor(ctx context.Context, userId, authorId string) error {
+	resp, err := r.db.GetDB().NewDelete().
+		Model((*model.SnoozedAuthor)(nil)).
+		Where("userId = ? AND authorId = ? AND until > ?", userId, authorId, time.Now()).
+		Exec(ctx)
+	if err != nil {
+		return err
+	} else if affected, _ := resp.RowsAffected(); affected < 1 {
+		return errors.New("this author is not snoozed")
+	}
+	return nil
+}
+
+func (r *NewsfeedRepo) GetHiddenPosts(ctx context.Context, userId string, limit, offset int) ([]model.HiddenPostInfo, error) {
+	hidden := make([]model.HiddenPostInfo, 0)
+	query := r.db.GetDB().NewSelect().
+		Column("hp.postId", "hp.reason", "p.content", "pf.firstname", "pf.lastname", "pf.avatarUrl").
+		ColumnExpr("hp.createdAt AS hiddenAt").
+		ColumnExpr("p.userId AS authorId").
+		TableExpr("hidden_posts as hp").
+		Join("JOIN posts p ON p.postId = hp.postId").
+		Join("JOIN profiles pf ON pf.userId = p.userId").
+		Where("hp.userId = ? AND p.deleted = 0", userId).
+		OrderExpr("hp.createdAt DESC")
+	if limit > 0 {
+		query.Limit(limit).Offset(offset)
+	}
+	err := query.Scan(ctx, &hidden)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return hidden, nil
+		}
+		return nil, err
+	}
+	return hidden, nil
+}
+
+// GetSnoozedAuthors returns the running snoozes of a user, ending soonest first
+func (r *NewsfeedRepo) GetSnoozedAuthors(ctx context.Context, userId string, now time.Time) ([]model.SnoozedAuthorInfo, error) {
+	snoozed := make([]model.SnoozedAuthorInfo, 0)
+	err := r.db.GetDB().NewSelect().
+		Column("sa.authorId", "sa.until", "pf.profileId", "pf.firstname", "pf.lastname", "pf.avatarUrl").
+		TableExpr("snoozed_authors as sa").
+		Join("JOIN profiles pf ON pf.userId = sa.authorId").
+		Where("sa.userId = ? AND sa.until > ?", userId, now).
+		OrderExpr("sa.until ASC").
+		Scan(ctx, &snoozed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return snoozed, nil
+		}
+		return nil, err
+	}
+	return snoozed, nil
+}
+
+// GetSnoozerIds returns the users currently snoozing authorId
+func (r *NewsfeedRepo) GetSnoozerIds(ctx context.Context, authorId string, now time.Time) ([]string, error) {
+	userIds := make([]string, 0)
+	err := r.db.GetDB().NewSelect().
+		Model((*model.SnoozedAuthor)(nil)).
+		Column("userId").
+		Where("authorId = ? AND until > ?", authorId, now).
+		Scan(ctx, &userIds)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return userIds, nil
+		}
+		return nil, err
+	}
+	return userIds, nil
+}
+
+// GetExpiredSnoozes returns up to limit snoozes that ran out before now,
+// oldest first
+func (r *NewsfeedRepo) GetExpiredSnoozes(ctx context.Context, now time.Time, limit int) ([]model.SnoozedAuthor, error) {
+	snoozes := make([]model.SnoozedAuthor, 0)
+	err := r.db.GetDB().NewSelect().
+		Model(&snoozes).
+		Where("until <= ?", now).
+		OrderExpr("until ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return snoozes, nil
+		}
+		return nil, err
+	}
+	return snoozes, nil
+}
+
+// DeleteExpiredSnooze forgets a snooze that ran out and reports whether it
+// did, a snooze renewed in the meantime is kept.
+func (r *NewsfeedRepo) DeleteExpiredSnooze(ctx context.Context, userId, authorId string, now time.Time) (bool, error) {
+	resp, err := r.db.GetDB().NewDelete().
+		Model((*model.SnoozedAuthor)(nil)).
+		Where("userId = ? AND authorId = ? AND until <= ?", userId, authorId, now).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	affected, _ := resp.RowsAffected()
+	return affected > 0, nil
+}
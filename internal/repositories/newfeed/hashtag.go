@@ -0,0 +1,136 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"program/internal/model"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/uptrace/bun"
+)
+
+// SetPostHashtagsTransaction replaces the hashtag index of a post
+func (r *NewsfeedRepo) SetPostHashtagsTransaction(ctx context.Context, tx *bun.Tx, postId string, tags []string) error {
+	_, err := tx.NewDelete().
+		Model((*model.PostHashtag)(nil)).
+		Where("postId = ?", postId).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	hashtags := make([]model.Hashtag, 0, len(tags))
+	for _, tag := range tags {
+		hashtags = append(hashtags, model.Hashtag{Tag: tag, CreatedAt: time.Now()})
+	}
+	_, err = tx.NewInsert().
+		Model(&hashtags).
+		Ignore().
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	hashtags = hashtags[:0]
+	err = tx.NewSelect().
+		Model(&hashtags).
+		Where("tag IN (?)", bun.In(tags)).
+		Scan(ctx)
+	if err != nil {
+		return err
+	}
+	links := make([]model.PostHashtag, 0, len(hashtags))
+	for _, hashtag := range hashtags {
+		links = append(links, model.PostHashtag{PostId: postId, HashtagId: hashtag.HashtagId})
+	}
+	_, err = tx.NewInsert().
+		Model(&links).
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) GetPostHashtags(ctx context.Context, postId string) ([]string, error) {
+	tags := make([]string, 0)
+	err := r.db.GetDB().NewSelect().
+		Column("h.tag").
+		TableExpr("post_hashtags as ph").
+		Join("JOIN hashtags h ON h.id = ph.hashtagId").
+		Where("ph.postId = ?", postId).
+		Scan(ctx, &tags)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return tags, nil
+		}
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetHashtagFeed returns the ids of the posts tagged with tag that user_id
+// can see, newest first.
+func (r *NewsfeedRepo) GetHashtagFeed(ctx context.Context, user_id, tag string, limit, offset int) ([]string, error) {
+	postIds := make([]string, 0)
+	query := r.db.GetDB().NewSelect().
+		Column("p.postId").
+		TableExpr("post_hashtags as ph").
+		Join("JOIN hashtags h ON h.id = ph.hashtagId").
+		Join("JOIN posts p ON p.postId = ph.postId").
+		Where("h.tag = ?", tag).
+		OrderExpr("p.createdAt DESC")
+	if limit > 0 {
+		query.Limit(limit).Offset(offset)
+	}
+	err := VisibleTo(query, user_id).Scan(ctx, &postIds)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return postIds, nil
+		}
+		return nil, err
+	}
+	return postIds, nil
+}
+
+// Trending Redis
+// Tag usage is counted in one sorted set per hour, trending tags are the
+// union of the buckets inside the window.
+func trendingKey(at time.Time) string {
+	return "trending:tags:" + at.UTC().Format("2006010215")
+}
+
+func (r *NewsfeedRepo) IncrementTrendingTags(ctx context.Context, tags []string, at time.Time, window time.Duration) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	key := trendingKey(at)
+	pipe := r.rdb.GetDB().Pipeline()
+	for _, tag := range tags {
+		pipe.ZIncrBy(ctx, key, 1, tag)
+	}
+	pipe.Expire(ctx, key, window+time.Hour)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) GetTrendingTags(ctx context.Context, now time.Time, window time.Duration, limit int) ([]model.TrendingTag, error) {
+	keys := make([]string, 0)
+	for at := now; now.Sub(at) < window; at = at.Add(-time.Hour) {
+		keys = append(keys, trendingKey(at))
+	}
+	res, err := r.rdb.GetDB().ZUnionWithScores(ctx, redis.ZStore{Keys: keys}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error reading trending tags: %w", err)
+	}
+	// ZUNION returns the lowest scores first
+	trending := make([]model.TrendingTag, 0, limit)
+	for i := len(res) - 1; i >= 0 && len(trending) < limit; i-- {
+		tag, ok := res[i].Member.(string)
+		if !ok {
+			continue
+		}
+		trending = append(trending, model.TrendingTag{Tag: tag, Score: res[i].Score})
+	}
+	return trending, nil
+}
@@ -0,0 +1,204 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"program/internal/model"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/uptrace/bun"
+)
+
+// impressionSeenTTL outlives the day so that late batches are still deduplicated
+const impressionSeenTTL = 48 * time.Hour
+
+// GetImpressionTargets keeps the reported posts the viewer can see, leaving
+// out their own posts.
+func (r *NewsfeedRepo) GetImpressionTargets(ctx context.Context, viewerId string, postIds []string) ([]model.ImpressionTarget, error) {
+	targets := make([]model.ImpressionTarget, 0)
+	if len(postIds) == 0 {
+		return targets, nil
+	}
+	query := r.db.GetDB().NewSelect().
+		Column("p.postId").
+		ColumnExpr("p.userId AS authorId").
+		ColumnExpr("EXISTS (SELECT 1 FROM follows f WHERE f.followerId = ? AND f.followingId = p.userId AND f.isActive = 1) AS follower", viewerId).
+		TableExpr("posts as p").
+		Where("p.postId IN (?) AND p.userId != ?", bun.In(postIds), viewerId)
+	err := VisibleTo(query, viewerId).Scan(ctx, &targets)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return targets, nil
+		}
+		return nil, err
+	}
+	return targets, nil
+}
+
+// MarkImpressionsSeen records that the viewer saw the posts on day and
+// returns the ones they had not seen yet that day.
+func (r *NewsfeedRepo) MarkImpressionsSeen(ctx context.Context, viewerId string, day time.Time, postIds []string) (map[string]bool, error) {
+	pipe := r.rdb.GetDB().Pipeline()
+	prefix := "impression:" + day.Format(time.DateOnly) + ":" + viewerId + ":"
+	cmds := make([]*redis.BoolCmd, 0, len(postIds))
+	for _, postId := range postIds {
+		cmds = append(cmds, pipe.SetNX(ctx, prefix+postId, 1, impressionSeenTTL))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	firstSeen := make(map[string]bool, len(postIds))
+	for i, postId := range postIds {
+		if cmds[i].Val() {
+			firstSeen[postId] = true
+		}
+	}
+	return firstSeen, nil
+}
+
+// UnmarkImpressionsSeen forgets that the viewer saw the posts on day, for
+// impressions that could not be stored.
+func (r *NewsfeedRepo) UnmarkImpressionsSeen(ctx context.Context, viewerId string, day time.Time, postIds []string) error {
+	if len(postIds) == 0 {
+		return nil
+	}
+	prefix := "impression:" + day.Format(time.DateOnly) + ":" + viewerId + ":"
+	keys := make([]string, 0, len(postIds))
+	for _, postId := range postIds {
+		keys = append(keys, prefix+postId)
+	}
+	return r.rdb.GetDB().Del(ctx, keys...).Err()
+}
+
+// AddPostDailyStats adds the counters to the rollups of their day
+func (r *NewsfeedRepo) AddPostDailyStats(ctx context.Context, stats []model.PostDailyStats) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	_, err := r.db.GetDB().NewInsert().
+		Model(&stats).
+		On("DUPLICATE KEY UPDATE").
+		Set("impressions = impressions + VALUES(impressions)").
+		Set("followerImpressions = followerImpressions + VALUES(followerImpressions)").
+		Set("nonFollowerImpressions = nonFollowerImpressions + VALUES(nonFollowerImpressions)").
+		Set("dwellMs = dwellMs + VALUES(dwellMs)").
+		Exec(ctx)
+	return err
+}
+
+// Lifetime reach is kept in two HyperLogLogs per post, for the viewers who
+// followed the author and for the others. They count distinct viewers within
+// about 1% in at most 12KB each, however many people see the post, where
+// storing every viewer would grow with the reach.
+func reachKey(postId string, follower bool) string {
+	if follower {
+		return "reach:" + postId + ":followers"
+	}
+	return "reach:" + postId + ":others"
+}
+
+// AddPostReach adds the viewer to the reach of the posts, given as postId to
+// whether the viewer follows the author.
+func (r *NewsfeedRepo) AddPostReach(ctx context.Context, viewerId string, posts map[string]bool) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	pipe := r.rdb.GetDB().Pipeline()
+	for postId, follower := range posts {
+		pipe.PFAdd(ctx, reachKey(postId, follower), viewerId)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) GetPostReach(ctx context.Context, postIds []string) (map[string]model.PostReach, error) {
+	reach := make(map[string]model.PostReach, len(postIds))
+	if len(postIds) == 0 {
+		return reach, nil
+	}
+	pipe := r.rdb.GetDB().Pipeline()
+	cmds := make([][3]*redis.IntCmd, 0, len(postIds))
+	for _, postId := range postIds {
+		followers, others := reachKey(postId, true), reachKey(postId, false)
+		cmds = append(cmds, [3]*redis.IntCmd{
+			pipe.PFCount(ctx, followers, others),
+			pipe.PFCount(ctx, followers),
+			pipe.PFCount(ctx, others),
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	for i, postId := range postIds {
+		reach[postId] = model.PostReach{
+			Reach:            cmds[i][0].Val(),
+			FollowerReach:    cmds[i][1].Val(),
+			NonFollowerReach: cmds[i][2].Val(),
+		}
+	}
+	return reach, nil
+}
+
+func (r *NewsfeedRepo) GetPostDailyStats(ctx context.Context, postId string, since time.Time) ([]model.PostDailyStats, error) {
+	stats := make([]model.PostDailyStats, 0)
+	err := r.db.GetDB().NewSelect().
+		Model(&stats).
+		Where("postId = ? AND day >= ?", postId, since).
+		OrderExpr("day ASC").
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return stats, nil
+		}
+		return nil, err
+	}
+	return stats, nil
+}
+
+// insightsQuery sums the rollups of the published posts of an author
+func (r *NewsfeedRepo) insightsQuery(authorId string) *bun.SelectQuery {
+	return r.db.GetDB().NewSelect().
+		Column("p.postId", "p.createdAt").
+		ColumnExpr("COALESCE(SUM(pds.impressions), 0) AS impressions").
+		ColumnExpr("COALESCE(SUM(pds.dwellMs), 0) AS dwellMs").
+		ColumnExpr("p.likeCount + p.commentCount + p.shareCount AS engagements").
+		TableExpr("posts as p").
+		Join("LEFT JOIN post_daily_stats pds ON pds.postId = p.postId").
+		Where("p.userId = ? AND p.deleted = 0 AND p.status = ?", authorId, model.PublishedPost).
+		GroupExpr("p.postId")
+}
+
+// GetPostInsights returns the insights of a post, only to its author
+func (r *NewsfeedRepo) GetPostInsights(ctx context.Context, authorId, postId string) (*model.PostInsights, error) {
+	insights := new(model.PostInsights)
+	err := r.insightsQuery(authorId).
+		Where("p.postId = ?", postId).
+		Scan(ctx, insights)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("this post was not found")
+		}
+		return nil, err
+	}
+	return insights, nil
+}
+
+// GetAuthorInsights lists the insights of an author's posts, newest first
+func (r *NewsfeedRepo) GetAuthorInsights(ctx context.Context, authorId string, limit, offset int) ([]model.PostInsights, error) {
+	insights := make([]model.PostInsights, 0)
+	query := r.insightsQuery(authorId).
+		OrderExpr("p.createdAt DESC")
+	if limit > 0 {
+		query.Limit(limit).Offset(offset)
+	}
+	err := query.Scan(ctx, &insights)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return insights, nil
+		}
+		return nil, err
+	}
+	return insights, nil
+}
@@ -0,0 +1,85 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"program/internal/model"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/uptrace/bun"
+)
+
+// SaveLinkPreview sets the preview of a post, replacing the previous one
+func (r *NewsfeedRepo) SaveLinkPreview(ctx context.Context, preview *model.LinkPreview) error {
+	_, err := r.db.GetDB().NewInsert().
+		Model(preview).
+		On("DUPLICATE KEY UPDATE").
+		Set("url = VALUES(url)").
+		Set("title = VALUES(title)").
+		Set("description = VALUES(description)").
+		Set("imageUrl = VALUES(imageUrl)").
+		Set("siteName = VALUES(siteName)").
+		Set("fetchedAt = VALUES(fetchedAt)").
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) DeleteLinkPreview(ctx context.Context, postId string) error {
+	_, err := r.db.GetDB().NewDelete().
+		Model((*model.LinkPreview)(nil)).
+		Where("postId = ?", postId).
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) GetLinkPreviews(ctx context.Context, postIds []string) ([]model.LinkPreview, error) {
+	previews := make([]model.LinkPreview, 0)
+	if len(postIds) == 0 {
+		return previews, nil
+	}
+	err := r.db.GetDB().NewSelect().
+		Model(&previews).
+		Where("postId IN (?)", bun.In(postIds)).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return previews, nil
+		}
+		return nil, err
+	}
+	return previews, nil
+}
+
+// Link previews Redis
+// Unfurled pages are cached per URL, so a link shared by many posts is only
+// fetched once per TTL. Failed fetches are cached as empty previews.
+func linkPreviewKey(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return "linkpreview:" + hex.EncodeToString(sum[:])
+}
+
+func (r *NewsfeedRepo) GetCachedLinkPreview(ctx context.Context, url string) (*model.LinkPreview, bool, error) {
+	raw, err := r.rdb.GetDB().Get(ctx, linkPreviewKey(url)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	preview := new(model.LinkPreview)
+	if err := json.Unmarshal(raw, preview); err != nil {
+		return nil, false, err
+	}
+	return preview, true, nil
+}
+
+func (r *NewsfeedRepo) CacheLinkPreview(ctx context.Context, url string, preview *model.LinkPreview, ttl time.Duration) error {
+	raw, err := json.Marshal(preview)
+	if err != nil {
+		return err
+	}
+	return r.rdb.GetDB().Set(ctx, linkPreviewKey(url), raw, ttl).Err()
+}
@@ -0,0 +1,81 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"program/internal/model"
+
+	"github.com/uptrace/bun"
+)
+
+func (r *NewsfeedRepo) ResolveUsernames(ctx context.Context, usernames []string) ([]model.User, error) {
+	users := make([]model.User, 0)
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	err := r.db.GetDB().NewSelect().
+		Model(&users).
+		Column("id", "username").
+		Where("username IN (?) AND deleted = 0", bun.In(usernames)).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return users, nil
+		}
+		return nil, err
+	}
+	return users, nil
+}
+
+// SetMentionsTransaction replaces the mentions of a post or comment
+func (r *NewsfeedRepo) SetMentionsTransaction(ctx context.Context, tx *bun.Tx, targetType model.MentionTarget, targetId string, mentions []model.Mention) error {
+	_, err := tx.NewDelete().
+		Model((*model.Mention)(nil)).
+		Where("targetType = ? AND targetId = ?", targetType, targetId).
+		Exec(ctx)
+	if err != nil || len(mentions) == 0 {
+		return err
+	}
+	_, err = tx.NewInsert().
+		Model(&mentions).
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) GetMentions(ctx context.Context, targetType model.MentionTarget, targetIds []string) ([]model.Mention, error) {
+	mentions := make([]model.Mention, 0)
+	if len(targetIds) == 0 {
+		return mentions, nil
+	}
+	err := r.db.GetDB().NewSelect().
+		Model(&mentions).
+		Where("targetType = ? AND targetId IN (?)", targetType, bun.In(targetIds)).
+		Order("offset ASC").
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return mentions, nil
+		}
+		return nil, err
+	}
+	return mentions, nil
+}
+
+// GetPostViewers returns the users among userIds allowed to see the post
+func (r *NewsfeedRepo) GetPostViewers(ctx context.Context, postId string, userIds []string) ([]string, error) {
+	viewers := make([]string, 0)
+	for _, userId := range userIds {
+		query := r.db.GetDB().NewSelect().
+			TableExpr("posts as p").
+			ColumnExpr("1").
+			Where("p.postId = ?", postId)
+		visible, err := VisibleTo(query, userId).Exists(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if visible {
+			viewers = append(viewers, userId)
+		}
+	}
+	return viewers, nil
+}
@@ -0,0 +1,49 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"errors"
+	"program/internal/model"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// GetModeratedPost finds a post that is live or hidden by moderation, unlike
+// GetPost which only finds live posts.
+func (r *NewsfeedRepo) GetModeratedPost(ctx context.Context, postId string) (*model.Post, error) {
+	post := new(model.Post)
+	err := r.db.GetDB().NewSelect().
+		Model(post).
+		Where("postId = ? AND deleted IN (0, ?)", postId, model.HiddenByModeration).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// SetPostModeratedTransaction takes a live post down or restores a post taken
+// down by a moderator. Posts deleted by their author are left alone.
+func (r *NewsfeedRepo) SetPostModeratedTransaction(ctx context.Context, tx *bun.Tx, postId string, hidden bool) error {
+	from, to := 0, model.HiddenByModeration
+	if !hidden {
+		from, to = model.HiddenByModeration, 0
+	}
+	resp, err := tx.NewUpdate().
+		Model((*model.Post)(nil)).
+		Set("deleted = ?", to).
+		Set("pinnedAt = NULL").
+		Set("updatedAt = ?", time.Now()).
+		Where("postId = ? AND deleted = ?", postId, from).
+		Exec(ctx)
+	if err != nil {
+		return err
+	} else if affected, _ := resp.RowsAffected(); affected < 1 {
+		if hidden {
+			return errors.New("this post is not live")
+		}
+		return errors.New("this post is not hidden by moderation")
+	}
+	return nil
+}
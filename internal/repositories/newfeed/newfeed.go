@@ -3,7 +3,6 @@ package newsfeedRepo
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"program/internal/database"
@@ -18,6 +17,51 @@ type NewsfeedRepo struct {
 	rdb database.IRedisConnection
 }
 
+var newsfeedColumns = []string{
+	"p.postId",
+	"p.userId",
+	"pf.avatarUrl",
+	"pf.firstname",
+	"pf.lastname",
+	"p.content",
+	"p.privacy",
+	"p.type",
+	"p.likeCount",
+	"p.commentCount",
+	"p.shareCount",
+	"p.reactionLike",
+	"p.reactionLove",
+	"p.reactionHaha",
+	"p.reactionWow",
+	"p.reactionSad",
+	"p.reactionAngry",
+	"p.sharedPostId",
+	"p.edited",
+	"p.status",
+	"p.publishAt",
+	"p.pinnedAt",
+	"p.createdAt",
+	"p.updatedAt",
+}
+
+// VisibleTo restricts a query on posts aliased as p to the published,
+// non-deleted posts viewerId is allowed to see: their own, public ones, and
+// friends-only posts of mutual followers.
+func VisibleTo(query *bun.SelectQuery, viewerId string) *bun.SelectQuery {
+	return query.
+		Where("p.deleted = 0 AND p.status = ?", model.PublishedPost).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("p.userId = ?", viewerId).
+				WhereOr("p.privacy = 'public'").
+				WhereOr("p.privacy = 'friends' AND EXISTS (SELECT 1 FROM follows fv WHERE fv.followerId = p.userId AND fv.followingId = ? AND fv.isMutual = 1 AND fv.isActive = 1)", viewerId).
+				WhereOr("p.privacy = 'custom' AND "+inAudience, viewerId)
+		})
+}
+
+// inAudience matches the custom posts whose audience lists contain the user
+// given as its only argument.
+const inAudience = "EXISTS (SELECT 1 FROM post_audiences pa JOIN audience_members am ON am.listId = pa.listId WHERE pa.postId = p.postId AND am.memberId = ?)"
+
 func NewNewsfeedRepo(db database.ISqlConnection, rdb database.IRedisConnection) INewsfeedRepo {
 	return &NewsfeedRepo{
 		db:  db,
@@ -30,94 +74,390 @@ func (r *NewsfeedRepo) GetDBTx(ctx context.Context) (*bun.Tx, error) {
 	return &tx, err
 }
 
-func (r *NewsfeedRepo) CreatePost(ctx context.Context, post *model.Post) (*model.NewsFeed, error) {
-	_, err := r.db.GetDB().NewInsert().
-		Model(post).
-		Exec(ctx)
+// GetTimelineSeed returns the recent posts used to rebuild a user's timeline
+// when it is missing from Redis: the user's own posts plus the visible posts
+// of everyone they follow.
+func (r *NewsfeedRepo) GetTimelineSeed(ctx context.Context, user_id string, windowDays, limit int) ([]model.TimelineEntry, error) {
+	entries := make([]model.TimelineEntry, 0)
+	othersQuery := r.db.GetDB().NewSelect().
+		Column("p.postId", "p.createdAt").
+		TableExpr("follows as f").
+		Join("JOIN posts p ON p.userId = f.followingId").
+		Where("f.followerId = ? AND f.isActive = 1 AND p.deleted = 0 AND p.status = 'published' AND p.createdAt >= NOW() - INTERVAL ? DAY AND (p.privacy = 'public' OR (p.privacy = 'friends' AND f.isMutual = 1) OR (p.privacy = 'custom' AND "+inAudience+")) AND "+notMuted, user_id, windowDays, user_id, user_id, user_id)
+
+	myQuery := r.db.GetDB().NewSelect().
+		Column("p.postId", "p.createdAt").
+		TableExpr("posts as p").
+		Where("p.userId = ? AND p.deleted = 0 AND p.status = 'published' AND p.createdAt >= NOW() - INTERVAL ? DAY", user_id, windowDays)
+
+	unionQuery := r.db.GetDB().NewSelect().With("others", othersQuery).With("mine", myQuery).TableExpr("(SELECT * FROM others UNION ALL SELECT * FROM mine) AS newsfeed").
+		OrderExpr("createdAt DESC")
+	if limit > 0 {
+		unionQuery.Limit(limit)
+	}
+	err := unionQuery.Scan(ctx, &entries)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return entries, nil
+		}
 		return nil, err
 	}
-	myPost := new(model.NewsFeed)
-	myQuery := r.db.GetDB().NewSelect().
-		Column(
-			"p.postId",
-			"pf.avatarUrl",
-			"pf.firstname",
-			"pf.lastname",
-			"p.content",
-			"p.privacy",
-			"p.likeCount",
-			"p.commentCount",
-			"p.shareCount",
-			"p.createdAt",
-			"p.updatedAt").
+	return entries, nil
+}
+
+// GetPostsByIds hydrates the given posts in a single query, dropping any the
+// viewer is no longer allowed to see. The result is not ordered and carries
+// no per-viewer state, see GetLikedPostIds.
+func (r *NewsfeedRepo) GetPostsByIds(ctx context.Context, user_id string, postIds []string) (*[]model.NewsFeed, error) {
+	newsfeed := new([]model.NewsFeed)
+	if len(postIds) == 0 {
+		return newsfeed, nil
+	}
+	query := r.db.GetDB().NewSelect().
+		Column(newsfeedColumns...).
 		TableExpr("posts as p").
 		Join("JOIN profiles pf ON pf.userId = p.userId").
-		Where("p.postId = ?", post.PostId)
-	err = myQuery.Scan(ctx, myPost)
+		Where("p.postId IN (?)", bun.In(postIds))
+	err := VisibleTo(query, user_id).Scan(ctx, newsfeed)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return myPost, nil
+			return newsfeed, nil
 		}
 		return nil, err
 	}
-	return myPost, nil
+	return newsfeed, nil
 }
 
-func (r *NewsfeedRepo) GetNewsfeed(ctx context.Context, limit, offset int, user_id string) (*[]model.NewsFeed, error) {
-	newsfeed := new([]model.NewsFeed)
-	othersQuery := r.db.GetDB().NewSelect().
-		Column(
-			"p.postId",
-			"pf.avatarUrl",
-			"pf.firstname",
-			"pf.lastname",
-			"p.content",
-			"p.privacy",
-			"p.likeCount",
-			"p.commentCount",
-			"p.shareCount",
-			"p.createdAt",
-			"p.updatedAt").
-		ColumnExpr("IF(l.postId IS NOT NULL AND l.isActive = 1,TRUE,FALSE) AS liked").
-		TableExpr("follows as f").
-		Join("JOIN profiles pf ON pf.userId = f.followingId").
-		Join("JOIN posts p ON p.userId = f.followingId").
-		Join("LEFT JOIN likes l ON l.postId = p.postId AND l.userId = ?", user_id).
-		Where("f.followerId = ? AND f.isActive = 1 AND p.deleted = 0 AND p.createdAt >= NOW() - INTERVAL 7 DAY AND (f.isMutual = 1 OR p.privacy = 'public')", user_id)
-
-	myQuery := r.db.GetDB().NewSelect().
-		Column(
-			"p.postId",
-			"pf.avatarUrl",
-			"pf.firstname",
-			"pf.lastname",
-			"p.content",
-			"p.privacy",
-			"p.likeCount",
-			"p.commentCount",
-			"p.shareCount",
-			"p.createdAt",
-			"p.updatedAt").
-		ColumnExpr("IF(l.postId IS NOT NULL AND l.isActive = 1,TRUE,FALSE) AS liked").
+// GetRecentPostRefs returns the newest posts of the given authors that the
+// viewer can see and did not mute, created after since.
+func (r *NewsfeedRepo) GetRecentPostRefs(ctx context.Context, user_id string, authorIds []string, since time.Time, limit int) ([]model.TimelineEntry, error) {
+	entries := make([]model.TimelineEntry, 0)
+	if len(authorIds) == 0 {
+		return entries, nil
+	}
+	query := r.db.GetDB().NewSelect().
+		Column("p.postId", "p.createdAt").
 		TableExpr("posts as p").
-		Join("JOIN profiles pf ON pf.userId = p.userId").
-		Join("LEFT JOIN likes l ON l.postId = p.postId AND l.userId = ?", user_id).
-		Where("p.userId = ? AND p.deleted = 0 AND p.createdAt >= NOW() - INTERVAL 7 DAY", user_id)
+		Where("p.userId IN (?) AND p.createdAt >= ?", bun.In(authorIds), since).
+		Where(notMuted, user_id, user_id).
+		OrderExpr("p.createdAt DESC")
+	if limit > 0 {
+		query.Limit(limit)
+	}
+	err := VisibleTo(query, user_id).Scan(ctx, &entries)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entries, nil
+		}
+		return nil, err
+	}
+	return entries, nil
+}
 
-	unionQuery := r.db.GetDB().NewSelect().With("others", othersQuery).With("mine", myQuery).TableExpr("(SELECT * FROM others UNION ALL SELECT * FROM mine) AS newsfeed").
-		OrderExpr("createdAt DESC")
+// GetOlderPostRefs pages through the feed straight from MySQL, past the
+// timeline window: the viewer's own posts and the visible posts of everyone
+// they follow, newest first, strictly before the (before, beforeId) position
+// and within [since, until). Zero times leave the bounds open.
+func (r *NewsfeedRepo) GetOlderPostRefs(ctx context.Context, user_id string, before time.Time, beforeId string, since, until time.Time, limit int) ([]model.TimelineEntry, error) {
+	entries := make([]model.TimelineEntry, 0)
+	query := r.db.GetDB().NewSelect().
+		Column("p.postId", "p.createdAt").
+		TableExpr("posts as p").
+		Where("(p.userId = ? OR EXISTS (SELECT 1 FROM follows f WHERE f.followerId = ? AND f.followingId = p.userId AND f.isActive = 1))", user_id, user_id).
+		Where(notMuted, user_id, user_id).
+		OrderExpr("p.createdAt DESC, p.postId DESC")
+	if !before.IsZero() {
+		query.Where("(p.createdAt < ? OR (p.createdAt = ? AND p.postId < ?))", before, before, beforeId)
+	}
+	if !since.IsZero() {
+		query.Where("p.createdAt >= ?", since)
+	}
+	if !until.IsZero() {
+		query.Where("p.createdAt < ?", until)
+	}
 	if limit > 0 {
-		unionQuery.Limit(limit).Offset(offset)
+		query.Limit(limit)
 	}
-	err := unionQuery.Scan(ctx, newsfeed)
+	err := VisibleTo(query, user_id).Scan(ctx, &entries)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return newsfeed, nil
+			return entries, nil
 		}
 		return nil, err
 	}
-	return newsfeed, nil
+	return entries, nil
+}
+
+func (r *NewsfeedRepo) GetAuthorPostIdsSince(ctx context.Context, authorId string, since time.Time) ([]string, error) {
+	postIds := make([]string, 0)
+	err := r.db.GetDB().NewSelect().
+		Model((*model.Post)(nil)).
+		Column("postId").
+		Where("userId = ? AND createdAt >= ?", authorId, since).
+		Scan(ctx, &postIds)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return postIds, nil
+		}
+		return nil, err
+	}
+	return postIds, nil
+}
+
+// GetFanOutTargets returns the followers whose timelines should receive a
+// post with the given privacy. Custom posts only reach the followers that are
+// in one of the audience lists of postId. Followers who hid the post are left
+// out, a post fanned out again after an edit must not come back to them.
+func (r *NewsfeedRepo) GetFanOutTargets(ctx context.Context, authorId, postId string, privacy model.Privacy) ([]string, error) {
+	followerIds := make([]string, 0)
+	if privacy == model.Private {
+		return followerIds, nil
+	}
+	query := r.db.GetDB().NewSelect().
+		Model((*model.Follows)(nil)).
+		Column("followerId").
+		Where("followingId = ? AND isActive = 1", authorId).
+		Where("followerId NOT IN (SELECT hp.userId FROM hidden_posts hp WHERE hp.postId = ?)", postId)
+	switch privacy {
+	case model.Friends:
+		query.Where("isMutual = 1")
+	case model.Custom:
+		query.Where("followerId IN (SELECT am.memberId FROM post_audiences pa JOIN audience_members am ON am.listId = pa.listId WHERE pa.postId = ?)", postId)
+	}
+	err := query.Scan(ctx, &followerIds)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return followerIds, nil
+		}
+		return nil, err
+	}
+	return followerIds, nil
+}
+
+func (r *NewsfeedRepo) CountFollowers(ctx context.Context, userId string) (int, error) {
+	return r.db.GetDB().NewSelect().
+		Model((*model.Follows)(nil)).
+		Where("followingId = ? AND isActive = 1", userId).
+		Count(ctx)
+}
+
+// GetFollowedCelebrities returns the accounts followed by user_id that have at
+// least threshold active followers. Their posts are pulled at read time
+// instead of being fanned out on write.
+func (r *NewsfeedRepo) GetFollowedCelebrities(ctx context.Context, user_id string, threshold int) ([]string, error) {
+	celebrities := make([]string, 0)
+	err := r.db.GetDB().NewSelect().
+		Column("f.followingId").
+		TableExpr("follows as f").
+		Where("f.followerId = ? AND f.isActive = 1", user_id).
+		Where("(SELECT COUNT(*) FROM follows fc WHERE fc.followingId = f.followingId AND fc.isActive = 1) >= ?", threshold).
+		Scan(ctx, &celebrities)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return celebrities, nil
+		}
+		return nil, err
+	}
+	return celebrities, nil
+}
+
+// GetLikedIds returns the targetIds of the given type that user_id likes
+func (r *NewsfeedRepo) GetLikedIds(ctx context.Context, user_id string, likeType model.LikeType, targetIds []string) ([]string, error) {
+	liked := make([]string, 0)
+	if len(targetIds) == 0 {
+		return liked, nil
+	}
+	err := r.db.GetDB().NewSelect().
+		Model((*model.Like)(nil)).
+		Column("targetId").
+		Where("userId = ? AND type = ? AND targetId IN (?) AND isActive = 1", user_id, likeType, bun.In(targetIds)).
+		Scan(ctx, &liked)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return liked, nil
+		}
+		return nil, err
+	}
+	return liked, nil
+}
+
+func (r *NewsfeedRepo) GetPost(ctx context.Context, postId string) (*model.Post, error) {
+	post := new(model.Post)
+	err := r.db.GetDB().NewSelect().
+		Model(post).
+		Where("postId = ? AND deleted = 0", postId).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+func (r *NewsfeedRepo) UpdatePost(ctx context.Context, tx *bun.Tx, postId string, fields map[string]any) error {
+	query := tx.NewUpdate().
+		Model((*model.Post)(nil)).
+		Where("postId = ? AND deleted = 0", postId)
+	for field, value := range fields {
+		query.Set(fmt.Sprintf("%s = ?", field), value)
+	}
+	resp, err := query.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update post: %v", err)
+	} else if affected, _ := resp.RowsAffected(); affected < 1 {
+		return errors.New("update post failed")
+	}
+	return nil
+}
+
+func (r *NewsfeedRepo) CreatePostTransaction(ctx context.Context, tx *bun.Tx, post *model.Post) error {
+	_, err := tx.NewInsert().
+		Model(post).
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) IncreaseShareCount(ctx context.Context, tx *bun.Tx, postId string) error {
+	_, err := tx.NewUpdate().Model((*model.Post)(nil)).
+		Set("shareCount = shareCount + 1").
+		Where("postId = ?", postId).
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) DecreaseShareCount(ctx context.Context, tx *bun.Tx, postId string) error {
+	_, err := tx.NewUpdate().Model((*model.Post)(nil)).
+		Set("shareCount = shareCount - 1").
+		Where("postId = ? AND shareCount > 0", postId).
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) IncreaseCommentCount(ctx context.Context, tx *bun.Tx, postId string) error {
+	_, err := tx.NewUpdate().Model((*model.Post)(nil)).
+		Set("commentCount = commentCount + 1").
+		Where("postId = ?", postId).
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) DecreaseCommentCount(ctx context.Context, tx *bun.Tx, postId string) error {
+	_, err := tx.NewUpdate().Model((*model.Post)(nil)).
+		Set("commentCount = commentCount - 1").
+		Where("postId = ? AND commentCount > 0", postId).
+		Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) DeletePost(ctx context.Context, tx *bun.Tx, postId string) error {
+	resp, err := tx.NewUpdate().
+		Model((*model.Post)(nil)).
+		Set("deleted = 1").
+		Set("pinnedAt = NULL").
+		Set("updatedAt = ?", time.Now()).
+		Where("postId = ? AND deleted = 0", postId).
+		Exec(ctx)
+	if err != nil {
+		return err
+	} else if affected, _ := resp.RowsAffected(); affected < 1 {
+		return errors.New("delete post failed")
+	}
+	return nil
+}
+
+// GetAuthorAffinity counts how often user_id liked or commented on posts of
+// each author since the given time.
+func (r *NewsfeedRepo) GetAuthorAffinity(ctx context.Context, user_id string, authorIds []string, since time.Time) ([]model.AuthorAffinity, error) {
+	affinity := make([]model.AuthorAffinity, 0)
+	if len(authorIds) == 0 {
+		return affinity, nil
+	}
+	likesQuery := r.db.GetDB().NewSelect().
+		ColumnExpr("targetId AS postId").
+		TableExpr("likes").
+		Where("userId = ? AND type = ? AND isActive = 1 AND createdAt >= ?", user_id, model.LikePost, since)
+	commentsQuery := r.db.GetDB().NewSelect().
+		Column("postId").
+		TableExpr("comments").
+		Where("userId = ? AND createdAt >= ?", user_id, since)
+	err := r.db.GetDB().NewSelect().
+		ColumnExpr("p.userId AS authorId").
+		ColumnExpr("COUNT(*) AS interactions").
+		TableExpr("(?) AS i", likesQuery.UnionAll(commentsQuery)).
+		Join("JOIN posts p ON p.postId = i.postId").
+		Where("p.userId IN (?)", bun.In(authorIds)).
+		GroupExpr("p.userId").
+		Scan(ctx, &affinity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return affinity, nil
+		}
+		return nil, err
+	}
+	return affinity, nil
+}
+
+func (r *NewsfeedRepo) GetMutualFollowings(ctx context.Context, user_id string, authorIds []string) ([]string, error) {
+	mutual := make([]string, 0)
+	if len(authorIds) == 0 {
+		return mutual, nil
+	}
+	err := r.db.GetDB().NewSelect().
+		Model((*model.Follows)(nil)).
+		Column("followingId").
+		Where("followerId = ? AND followingId IN (?) AND isActive = 1 AND isMutual = 1", user_id, bun.In(authorIds)).
+		Scan(ctx, &mutual)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return mutual, nil
+		}
+		return nil, err
+	}
+	return mutual, nil
+}
+
+func (r *NewsfeedRepo) CreateMedia(ctx context.Context, media *model.PostMedia) error {
+	_, err := r.db.GetDB().NewInsert().
+		Model(media).
+		Exec(ctx)
+	return err
+}
+
+// AttachMediaTransaction attaches the uploader's unattached media to a post in
+// the given order. It fails if any media is missing, foreign or already used.
+func (r *NewsfeedRepo) AttachMediaTransaction(ctx context.Context, tx *bun.Tx, userId, postId string, mediaIds []string) error {
+	for position, mediaId := range mediaIds {
+		resp, err := tx.NewUpdate().
+			Model((*model.PostMedia)(nil)).
+			Set("postId = ?", postId).
+			Set("position = ?", position).
+			Where("mediaId = ? AND userId = ? AND postId IS NULL", mediaId, userId).
+			Exec(ctx)
+		if err != nil {
+			return err
+		} else if affected, _ := resp.RowsAffected(); affected < 1 {
+			return fmt.Errorf("media %s can not be attached", mediaId)
+		}
+	}
+	return nil
+}
+
+func (r *NewsfeedRepo) GetMediaForPosts(ctx context.Context, postIds []string) ([]model.PostMedia, error) {
+	media := make([]model.PostMedia, 0)
+	if len(postIds) == 0 {
+		return media, nil
+	}
+	err := r.db.GetDB().NewSelect().
+		Model(&media).
+		Where("postId IN (?)", bun.In(postIds)).
+		Order("position ASC").
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return media, nil
+		}
+		return nil, err
+	}
+	return media, nil
 }
 
 func (r *NewsfeedRepo) CreateLike(ctx context.Context, tx *bun.Tx, like *model.Like) error {
@@ -150,24 +490,11 @@ func (r *NewsfeedRepo) DecreaseLikeCount(ctx context.Context, tx *bun.Tx, postId
 	return err
 }
 
-func (r *NewsfeedRepo) IsLikeExisted(ctx context.Context, postId, userId string) (bool, error) {
-	exists, err := r.db.GetDB().NewSelect().
-		Model((*model.Like)(nil)).
-		ColumnExpr("1").
-		Where("postId = ?", postId).
-		Where("userId = ?", userId).
-		Exists(ctx)
-	if err != nil {
-		return false, fmt.Errorf("error checking like post: %w", err)
-	}
-	return exists, nil
-}
-
 func (r *NewsfeedRepo) IsPostExisted(ctx context.Context, postId string) (bool, error) {
 	exists, err := r.db.GetDB().NewSelect().
 		Model((*model.Post)(nil)).
 		ColumnExpr("1").
-		Where("postId = ?", postId).
+		Where("postId = ? AND deleted = 0 AND status = ?", postId, model.PublishedPost).
 		Exists(ctx)
 	if err != nil {
 		return false, fmt.Errorf("error checking exist post: %w", err)
@@ -175,18 +502,6 @@ func (r *NewsfeedRepo) IsPostExisted(ctx context.Context, postId string) (bool,
 	return exists, nil
 }
 
-func (r *NewsfeedRepo) IsActiveLike(ctx context.Context, post_id, user_id string) (bool, error) {
-	isActive, err := r.db.GetDB().NewSelect().
-		Model((*model.Like)(nil)).
-		ColumnExpr("1").
-		Where("userId = ? AND postId = ? AND isActive = ?", user_id, post_id, true).
-		Exists(ctx)
-	if err != nil {
-		return false, fmt.Errorf("error checking like status: %w", err)
-	}
-	return isActive, nil
-}
-
 func (r *NewsfeedRepo) IsOwnPost(ctx context.Context, post_id, user_id string) (bool, error) {
 	isOwnPost, err := r.db.GetDB().NewSelect().
 		Model((*model.Post)(nil)).
@@ -199,24 +514,29 @@ func (r *NewsfeedRepo) IsOwnPost(ctx context.Context, post_id, user_id string) (
 	return isOwnPost, nil
 }
 
-func (r *NewsfeedRepo) UpdateLikeTransaction(ctx context.Context, tx *bun.Tx, user_id, post_id string, status bool) error {
+func (r *NewsfeedRepo) UpdateLikeTransaction(ctx context.Context, tx *bun.Tx, user_id string, likeType model.LikeType, target_id string, status bool) error {
 	_, err := tx.NewUpdate().
 		Model((*model.Like)(nil)).
 		Set("isActive = ?", status).
 		Set("updatedAt = ?", time.Now()).
-		Where("userId = ? AND postId = ?", user_id, post_id).
+		Where("userId = ? AND type = ? AND targetId = ?", user_id, likeType, target_id).
 		Exec(ctx)
 	return err
 }
 
-func (r *NewsfeedRepo) GetLikers(ctx context.Context, limit, offset int, post_id string) (*[]model.LikerInfo, error) {
+// GetLikers lists who reacted to a post, only with the given reaction unless
+// it is empty.
+func (r *NewsfeedRepo) GetLikers(ctx context.Context, limit, offset int, post_id string, reaction model.Reaction) (*[]model.LikerInfo, error) {
 	likers := new([]model.LikerInfo)
 	query := r.db.GetDB().NewSelect().
-		Column("p.profileId", "p.firstname", "p.lastname", "p.avatarUrl").
+		Column("p.profileId", "p.firstname", "p.lastname", "p.avatarUrl", "l.reaction").
 		TableExpr("likes as l").
 		Join("JOIN profiles p ON p.userId=l.userId").
-		Where("postId = ? AND isActive = 1", post_id).
+		Where("l.type = ? AND l.targetId = ? AND l.isActive = 1", model.LikePost, post_id).
 		Order("p.lastname ASC")
+	if reaction != "" {
+		query.Where("l.reaction = ?", reaction)
+	}
 	if limit > 0 {
 		query.Limit(limit).Offset(offset)
 	}
@@ -230,57 +550,38 @@ func (r *NewsfeedRepo) GetLikers(ctx context.Context, limit, offset int, post_id
 	return likers, nil
 }
 
+// CheckPublicPrivacyPermission fails unless a guest can see the post: it is
+// live and public, see VisibleTo.
 func (r *NewsfeedRepo) CheckPublicPrivacyPermission(ctx context.Context, postId string) error {
-	post := new(model.Post)
-	query := r.db.GetDB().NewSelect().
-		Model(post).Where("postId = ?", postId)
-	if err := query.Scan(ctx, post); err != nil {
-		return err
-	}
-
-	if post.Privacy != "public" {
-		return errors.New("you don't have permission to get likers in this post")
-	}
-	return nil
+	return r.CheckFriendPrivacyPermission(ctx, "guest", postId)
 }
 
+// CheckFriendPrivacyPermission fails unless userId can see the post, see
+// VisibleTo.
 func (r *NewsfeedRepo) CheckFriendPrivacyPermission(ctx context.Context, userId string, postId string) error {
-	post := new(model.Post)
-	query := r.db.GetDB().NewSelect().Model(post).Where("postId = ?", postId)
-	if err := query.Scan(ctx, post); err != nil {
-		return err
-	}
-	if post.Privacy == "public" {
-		return nil
-	}
-	friendCheck, err := r.db.GetDB().NewSelect().
-		TableExpr("follows as f").
-		Where("f.followerId = ? AND f.followingId = ? AND isMutual = 1", post.UserId, userId).Exists(ctx)
+	query := r.db.GetDB().NewSelect().
+		TableExpr("posts as p").
+		Where("p.postId = ?", postId)
+	visible, err := VisibleTo(query, userId).Exists(ctx)
 	if err != nil {
 		return err
 	}
-	if userId != post.UserId && friendCheck == false {
+	if !visible {
 		return errors.New("you don't have permission to get likers in this post")
 	}
 	return nil
 }
-func (r *NewsfeedRepo) CreateComment(ctx context.Context, commentPost *model.Comment) (*model.CommentInfo, error) {
-	_, err := r.db.GetDB().NewInsert().
+
+func (r *NewsfeedRepo) CreateComment(ctx context.Context, tx *bun.Tx, commentPost *model.Comment) (*model.CommentInfo, error) {
+	_, err := tx.NewInsert().
 		Model(commentPost).
 		Exec(ctx)
 	if err != nil {
 		return nil, err
 	}
 	myComment := new(model.CommentInfo)
-	myQuery := r.db.GetDB().NewSelect().
-		Column(
-			"c.commentId",
-			"pf.profileId",
-			"pf.firstname",
-			"pf.lastname",
-			"pf.avatarUrl",
-			"c.content",
-			"c.createdAt").
+	myQuery := tx.NewSelect().
+		Column(commentColumns...).
 		TableExpr("comments as c").
 		Join("JOIN profiles pf ON pf.userId = c.userId").
 		Where("c.commentId = ?", commentPost.CommentId)
@@ -294,14 +595,15 @@ func (r *NewsfeedRepo) CreateComment(ctx context.Context, commentPost *model.Com
 	return myComment, nil
 }
 
-func (r *NewsfeedRepo) GetComments(ctx context.Context, limit, offset int, postId string) (*[]model.CommentInfo, error) {
+func (r *NewsfeedRepo) GetComments(ctx context.Context, limit, offset int, postId, viewerId string, showHidden bool) (*[]model.CommentInfo, error) {
 	comments := new([]model.CommentInfo)
 	query := r.db.GetDB().NewSelect().
-		Column("c.commentId", "p.profileId", "p.firstname", "p.lastname", "p.avatarUrl", "c.createdAt", "c.content").
+		Column(commentColumns...).
 		TableExpr("comments as c").
-		Join("JOIN profiles p ON p.userId = c.userId").
-		Where("c.postId = ?", postId).
-		OrderExpr("createdAt DESC")
+		Join("JOIN profiles pf ON pf.userId = c.userId").
+		Where("c.postId = ? AND c.parentId IS NULL", postId).
+		OrderExpr("c.createdAt DESC")
+	visibleComments(query, viewerId, showHidden, true)
 	if limit > 0 {
 		query.Limit(limit).Offset(offset)
 	}
@@ -316,12 +618,14 @@ func (r *NewsfeedRepo) GetComments(ctx context.Context, limit, offset int, postI
 	return comments, nil
 }
 
-func (r *NewsfeedRepo) PutComment(ctx context.Context, commentId string, content string) error {
-	commentPut := model.Comment{
-		Content:   content,
-		UpdatedAt: time.Now(),
-	}
-	resp, err := r.db.GetDB().NewUpdate().Model(commentPut).WherePK("commentId = ?", commentId).Exec(ctx)
+func (r *NewsfeedRepo) PutComment(ctx context.Context, tx *bun.Tx, commentId string, content string) error {
+	resp, err := tx.NewUpdate().
+		Model((*model.Comment)(nil)).
+		Set("content = ?", content).
+		Set("edited = 1").
+		Set("updatedAt = ?", time.Now()).
+		Where("commentId = ? AND status != ?", commentId, model.DeletedComment).
+		Exec(ctx)
 	if err != nil {
 		return err
 	} else if affected, _ := resp.RowsAffected(); affected < 1 {
@@ -329,16 +633,3 @@ func (r *NewsfeedRepo) PutComment(ctx context.Context, commentId string, content
 	}
 	return nil
 }
-
-// Cache Redis
-func (r *NewsfeedRepo) SaveNewsfeedCache(ctx context.Context, key string, newsfeed *[]model.NewsFeed) error {
-	data, err := json.Marshal(newsfeed)
-	if err != nil {
-		return err
-	}
-	_, err = r.rdb.GetDB().HSet(ctx, key, data).Result()
-	if err != nil {
-		return err
-	}
-	return nil
-}
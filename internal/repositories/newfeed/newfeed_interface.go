@@ -3,30 +3,154 @@ package newsfeedRepo
 import (
 	"context"
 	"program/internal/model"
+	"time"
 
 	"github.com/uptrace/bun"
 )
 
 type INewsfeedRepo interface {
 	GetDBTx(ctx context.Context) (*bun.Tx, error)
-	CreatePost(ctx context.Context, post *model.Post) (*model.NewsFeed, error)
-	GetNewsfeed(ctx context.Context, limit, offset int, user_id string) (*[]model.NewsFeed, error)
+	GetTimelineSeed(ctx context.Context, user_id string, windowDays, limit int) ([]model.TimelineEntry, error)
+	GetPostsByIds(ctx context.Context, user_id string, postIds []string) (*[]model.NewsFeed, error)
+	GetLikedIds(ctx context.Context, user_id string, likeType model.LikeType, targetIds []string) ([]string, error)
+	GetPost(ctx context.Context, postId string) (*model.Post, error)
+	UpdatePost(ctx context.Context, tx *bun.Tx, postId string, fields map[string]any) error
+	CreatePostTransaction(ctx context.Context, tx *bun.Tx, post *model.Post) error
+	IncreaseShareCount(ctx context.Context, tx *bun.Tx, postId string) error
+	DecreaseShareCount(ctx context.Context, tx *bun.Tx, postId string) error
+	DeletePost(ctx context.Context, tx *bun.Tx, postId string) error
+	CreateMedia(ctx context.Context, media *model.PostMedia) error
+	AttachMediaTransaction(ctx context.Context, tx *bun.Tx, userId, postId string, mediaIds []string) error
+	GetMediaForPosts(ctx context.Context, postIds []string) ([]model.PostMedia, error)
+	GetRecentPostRefs(ctx context.Context, user_id string, authorIds []string, since time.Time, limit int) ([]model.TimelineEntry, error)
+	GetOlderPostRefs(ctx context.Context, user_id string, before time.Time, beforeId string, since, until time.Time, limit int) ([]model.TimelineEntry, error)
+	GetAuthorPostIdsSince(ctx context.Context, authorId string, since time.Time) ([]string, error)
+	GetAuthorAffinity(ctx context.Context, user_id string, authorIds []string, since time.Time) ([]model.AuthorAffinity, error)
+	GetMutualFollowings(ctx context.Context, user_id string, authorIds []string) ([]string, error)
+	GetFanOutTargets(ctx context.Context, authorId, postId string, privacy model.Privacy) ([]string, error)
+	CountFollowers(ctx context.Context, userId string) (int, error)
+	GetFollowedCelebrities(ctx context.Context, user_id string, threshold int) ([]string, error)
 	CreateLike(ctx context.Context, tx *bun.Tx, like *model.Like) error
 	IncreaseLikeCount(ctx context.Context, tx *bun.Tx, postId string) error
 	DecreaseLikeCount(ctx context.Context, tx *bun.Tx, postId string) error
-	IsLikeExisted(ctx context.Context, postId, userId string) (bool, error)
-	IsActiveLike(ctx context.Context, post_id, user_id string) (bool, error)
-	UpdateLikeTransaction(ctx context.Context, tx *bun.Tx, user_id, post_id string, status bool) error
+	UpdateLikeTransaction(ctx context.Context, tx *bun.Tx, user_id string, likeType model.LikeType, target_id string, status bool) error
 	IsPostExisted(ctx context.Context, postId string) (bool, error)
-	GetLikers(ctx context.Context, limit, offset int, post_id string) (*[]model.LikerInfo, error)
+	GetLikers(ctx context.Context, limit, offset int, post_id string, reaction model.Reaction) (*[]model.LikerInfo, error)
 	CheckPublicPrivacyPermission(ctx context.Context, postId string) error
 	CheckFriendPrivacyPermission(ctx context.Context, userId string, postId string) error
-	CreateComment(ctx context.Context, commentPost *model.Comment) (*model.CommentInfo, error)
-	GetComments(ctx context.Context, limit, offset int, postId string) (*[]model.CommentInfo, error)
+	CreateComment(ctx context.Context, tx *bun.Tx, commentPost *model.Comment) (*model.CommentInfo, error)
+	GetComments(ctx context.Context, limit, offset int, postId, viewerId string, showHidden bool) (*[]model.CommentInfo, error)
 	IsOwnPost(ctx context.Context, post_id, user_id string) (bool, error)
 	SetOwnerLikedStatus(ctx context.Context, tx *bun.Tx, postId string, status bool) error
-	PutComment(ctx context.Context, commentId string, content string) error
+	PutComment(ctx context.Context, tx *bun.Tx, commentId string, content string) error
 
-	//Redis Cache
-	SaveNewsfeedCache(ctx context.Context, key string, newsfeed *[]model.NewsFeed) error
+	//Comments
+	GetComment(ctx context.Context, commentId string) (*model.Comment, error)
+	IncreaseCommentCount(ctx context.Context, tx *bun.Tx, postId string) error
+	DecreaseCommentCount(ctx context.Context, tx *bun.Tx, postId string) error
+	IncreaseReplyCount(ctx context.Context, tx *bun.Tx, commentId string) error
+	DecreaseReplyCount(ctx context.Context, tx *bun.Tx, commentId string) error
+	SetCommentStatus(ctx context.Context, tx *bun.Tx, commentId string, status model.CommentStatus) error
+	IncreaseCommentLikeCount(ctx context.Context, tx *bun.Tx, commentId string) error
+	DecreaseCommentLikeCount(ctx context.Context, tx *bun.Tx, commentId string) error
+	GetReplies(ctx context.Context, limit, offset int, postId, parentId, viewerId string, showHidden bool) (*[]model.CommentInfo, error)
+	GetReplyPreviews(ctx context.Context, parentIds []string, perParent int, viewerId string, showHidden bool) ([]model.CommentInfo, error)
+	GetRole(ctx context.Context, userId string) (model.Role, error)
+
+	//Counters reconciliation
+	FindCounterDrift(ctx context.Context) ([]model.CounterDrift, error)
+	FixCounterDrift(ctx context.Context, tx *bun.Tx, drift []model.CounterDrift) error
+
+	//Reactions
+	GetLikeTransaction(ctx context.Context, tx *bun.Tx, likeType model.LikeType, targetId, userId string) (*model.Like, error)
+	SetReactionTransaction(ctx context.Context, tx *bun.Tx, userId, postId string, reaction model.Reaction, active bool) error
+	UpdateReactionCounts(ctx context.Context, tx *bun.Tx, postId string, added, removed model.Reaction) error
+	GetReactions(ctx context.Context, userId string, postIds []string) ([]model.Like, error)
+
+	//Revisions
+	CreatePostRevision(ctx context.Context, tx *bun.Tx, revision *model.PostRevision) error
+	GetPostRevisions(ctx context.Context, postId string) ([]model.PostRevision, error)
+	CreateCommentRevision(ctx context.Context, tx *bun.Tx, revision *model.CommentRevision) error
+	GetCommentRevisions(ctx context.Context, commentId string) ([]model.CommentRevision, error)
+
+	//Drafts and scheduled posts
+	GetDrafts(ctx context.Context, userId string, limit, offset int) (*[]model.NewsFeed, error)
+	GetDuePosts(ctx context.Context, now time.Time, limit int) ([]model.Post, error)
+	PublishPostTransaction(ctx context.Context, tx *bun.Tx, postId string, publishedAt time.Time) error
+	UnschedulePost(ctx context.Context, postId string) error
+
+	//Bookmarks
+	SaveBookmark(ctx context.Context, bookmark *model.Bookmark) error
+	DeleteBookmark(ctx context.Context, userId, postId string) error
+	GetBookmarkedIds(ctx context.Context, userId string, postIds []string) ([]string, error)
+	GetBookmarks(ctx context.Context, userId, collection string, afterTime time.Time, afterId string, limit int) ([]model.Bookmark, error)
+	GetBookmarkCollections(ctx context.Context, userId string) ([]model.BookmarkCollection, error)
+
+	//Polls
+	CreatePollTransaction(ctx context.Context, tx *bun.Tx, poll *model.Poll, options []model.PollOption) error
+	GetPolls(ctx context.Context, postIds []string) ([]model.Poll, error)
+	GetPollOptions(ctx context.Context, postIds []string) ([]model.PollOption, error)
+	GetPollVotes(ctx context.Context, userId string, postIds []string) ([]model.PollVote, error)
+	LockPollTransaction(ctx context.Context, tx *bun.Tx, postId string) (*model.Poll, error)
+	HasVotedTransaction(ctx context.Context, tx *bun.Tx, postId, userId string) (bool, error)
+	CreatePollVotesTransaction(ctx context.Context, tx *bun.Tx, votes []model.PollVote) error
+
+	//Link previews
+	SaveLinkPreview(ctx context.Context, preview *model.LinkPreview) error
+	DeleteLinkPreview(ctx context.Context, postId string) error
+	GetLinkPreviews(ctx context.Context, postIds []string) ([]model.LinkPreview, error)
+	GetCachedLinkPreview(ctx context.Context, url string) (*model.LinkPreview, bool, error)
+	CacheLinkPreview(ctx context.Context, url string, preview *model.LinkPreview, ttl time.Duration) error
+
+	//Pinned posts
+	CountPinnedPostsTransaction(ctx context.Context, tx *bun.Tx, userId string) (int, error)
+	SetPinnedTransaction(ctx context.Context, tx *bun.Tx, postId string, pinned bool) error
+	GetUserPostIds(ctx context.Context, viewerId, authorId string, limit, offset int) ([]string, error)
+
+	//Audience lists
+	CountOwnedAudienceLists(ctx context.Context, userId string, listIds []string) (int, error)
+	SetPostAudiencesTransaction(ctx context.Context, tx *bun.Tx, postId string, listIds []string) error
+
+	//Hidden posts and snoozed authors
+	HidePost(ctx context.Context, hidden *model.HiddenPost) error
+	UnhidePost(ctx context.Context, userId, postId string) error
+	SnoozeAuthor(ctx context.Context, snooze *model.SnoozedAuthor) error
+	UnsnoozeAuthor(ctx context.Context, userId, authorId string) error
+	GetHiddenPosts(ctx context.Context, userId string, limit, offset int) ([]model.HiddenPostInfo, error)
+	GetSnoozedAuthors(ctx context.Context, userId string, now time.Time) ([]model.SnoozedAuthorInfo, error)
+	GetSnoozerIds(ctx context.Context, authorId string, now time.Time) ([]string, error)
+	GetExpiredSnoozes(ctx context.Context, now time.Time, limit int) ([]model.SnoozedAuthor, error)
+	DeleteExpiredSnooze(ctx context.Context, userId, authorId string, now time.Time) (bool, error)
+
+	//Moderation
+	GetModeratedPost(ctx context.Context, postId string) (*model.Post, error)
+	SetPostModeratedTransaction(ctx context.Context, tx *bun.Tx, postId string, hidden bool) error
+
+	//Content filters
+	GetFilterCounter(ctx context.Context, key string) (int64, error)
+	IncrementFilterCounter(ctx context.Context, key string, window time.Duration) (int64, error)
+
+	//Hashtags
+	SetPostHashtagsTransaction(ctx context.Context, tx *bun.Tx, postId string, tags []string) error
+	GetPostHashtags(ctx context.Context, postId string) ([]string, error)
+	GetHashtagFeed(ctx context.Context, user_id, tag string, limit, offset int) ([]string, error)
+	IncrementTrendingTags(ctx context.Context, tags []string, at time.Time, window time.Duration) error
+	GetTrendingTags(ctx context.Context, now time.Time, window time.Duration, limit int) ([]model.TrendingTag, error)
+
+	//Mentions
+	ResolveUsernames(ctx context.Context, usernames []string) ([]model.User, error)
+	SetMentionsTransaction(ctx context.Context, tx *bun.Tx, targetType model.MentionTarget, targetId string, mentions []model.Mention) error
+	GetMentions(ctx context.Context, targetType model.MentionTarget, targetIds []string) ([]model.Mention, error)
+	GetPostViewers(ctx context.Context, postId string, userIds []string) ([]string, error)
+
+	//Insights
+	GetImpressionTargets(ctx context.Context, viewerId string, postIds []string) ([]model.ImpressionTarget, error)
+	MarkImpressionsSeen(ctx context.Context, viewerId string, day time.Time, postIds []string) (map[string]bool, error)
+	UnmarkImpressionsSeen(ctx context.Context, viewerId string, day time.Time, postIds []string) error
+	AddPostDailyStats(ctx context.Context, stats []model.PostDailyStats) error
+	AddPostReach(ctx context.Context, viewerId string, posts map[string]bool) error
+	GetPostReach(ctx context.Context, postIds []string) (map[string]model.PostReach, error)
+	GetPostDailyStats(ctx context.Context, postId string, since time.Time) ([]model.PostDailyStats, error)
+	GetPostInsights(ctx context.Context, authorId, postId string) (*model.PostInsights, error)
+	GetAuthorInsights(ctx context.Context, authorId string, limit, offset int) ([]model.PostInsights, error)
 }
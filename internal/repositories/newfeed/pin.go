@@ -0,0 +1,68 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"program/internal/model"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// CountPinnedPostsTransaction counts the pinned posts of a user and locks
+// them until tx ends, so that concurrent pins can not exceed the limit.
+func (r *NewsfeedRepo) CountPinnedPostsTransaction(ctx context.Context, tx *bun.Tx, userId string) (int, error) {
+	pinned := make([]string, 0)
+	err := tx.NewSelect().
+		Model((*model.Post)(nil)).
+		Column("postId").
+		Where("userId = ? AND pinnedAt IS NOT NULL", userId).
+		For("UPDATE").
+		Scan(ctx, &pinned)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	return len(pinned), nil
+}
+
+// SetPinnedTransaction pins or unpins a live post
+func (r *NewsfeedRepo) SetPinnedTransaction(ctx context.Context, tx *bun.Tx, postId string, pinned bool) error {
+	query := tx.NewUpdate().
+		Model((*model.Post)(nil)).
+		Where("postId = ? AND deleted = 0", postId)
+	if pinned {
+		query.Set("pinnedAt = ?", time.Now())
+	} else {
+		query.Set("pinnedAt = NULL")
+	}
+	resp, err := query.Exec(ctx)
+	if err != nil {
+		return err
+	} else if affected, _ := resp.RowsAffected(); affected < 1 {
+		return errors.New("update pinned post failed")
+	}
+	return nil
+}
+
+// GetUserPostIds returns the ids of the posts of authorId that viewerId can
+// see, pinned posts first, most recently pinned first, then newest first.
+func (r *NewsfeedRepo) GetUserPostIds(ctx context.Context, viewerId, authorId string, limit, offset int) ([]string, error) {
+	postIds := make([]string, 0)
+	query := r.db.GetDB().NewSelect().
+		Column("p.postId").
+		TableExpr("posts as p").
+		Where("p.userId = ?", authorId).
+		OrderExpr("p.pinnedAt IS NULL, p.pinnedAt DESC, p.createdAt DESC")
+	if limit > 0 {
+		query.Limit(limit).Offset(offset)
+	}
+	err := VisibleTo(query, viewerId).Scan(ctx, &postIds)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return postIds, nil
+		}
+		return nil, err
+	}
+	return postIds, nil
+}
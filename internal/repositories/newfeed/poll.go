@@ -0,0 +1,113 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"program/internal/model"
+
+	"github.com/uptrace/bun"
+)
+
+func (r *NewsfeedRepo) CreatePollTransaction(ctx context.Context, tx *bun.Tx, poll *model.Poll, options []model.PollOption) error {
+	if _, err := tx.NewInsert().Model(poll).Exec(ctx); err != nil {
+		return err
+	}
+	_, err := tx.NewInsert().Model(&options).Exec(ctx)
+	return err
+}
+
+func (r *NewsfeedRepo) GetPolls(ctx context.Context, postIds []string) ([]model.Poll, error) {
+	polls := make([]model.Poll, 0)
+	if len(postIds) == 0 {
+		return polls, nil
+	}
+	err := r.db.GetDB().NewSelect().
+		Model(&polls).
+		Where("postId IN (?)", bun.In(postIds)).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return polls, nil
+		}
+		return nil, err
+	}
+	return polls, nil
+}
+
+// GetPollOptions returns the options of the given polls in display order
+func (r *NewsfeedRepo) GetPollOptions(ctx context.Context, postIds []string) ([]model.PollOption, error) {
+	options := make([]model.PollOption, 0)
+	if len(postIds) == 0 {
+		return options, nil
+	}
+	err := r.db.GetDB().NewSelect().
+		Model(&options).
+		Where("postId IN (?)", bun.In(postIds)).
+		OrderExpr("postId, position ASC").
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return options, nil
+		}
+		return nil, err
+	}
+	return options, nil
+}
+
+// GetPollVotes returns the options userId chose in the given polls
+func (r *NewsfeedRepo) GetPollVotes(ctx context.Context, userId string, postIds []string) ([]model.PollVote, error) {
+	votes := make([]model.PollVote, 0)
+	if len(postIds) == 0 {
+		return votes, nil
+	}
+	err := r.db.GetDB().NewSelect().
+		Model(&votes).
+		Where("userId = ? AND postId IN (?)", userId, bun.In(postIds)).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return votes, nil
+		}
+		return nil, err
+	}
+	return votes, nil
+}
+
+// LockPollTransaction reads a poll and locks it until tx ends, so that the
+// votes of one user are checked and counted as a whole.
+func (r *NewsfeedRepo) LockPollTransaction(ctx context.Context, tx *bun.Tx, postId string) (*model.Poll, error) {
+	poll := new(model.Poll)
+	err := tx.NewSelect().
+		Model(poll).
+		Where("postId = ?", postId).
+		For("UPDATE").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+func (r *NewsfeedRepo) HasVotedTransaction(ctx context.Context, tx *bun.Tx, postId, userId string) (bool, error) {
+	return tx.NewSelect().
+		Model((*model.PollVote)(nil)).
+		Where("postId = ? AND userId = ?", postId, userId).
+		Exists(ctx)
+}
+
+// CreatePollVotesTransaction stores the votes and counts them on their
+// options in the same transaction.
+func (r *NewsfeedRepo) CreatePollVotesTransaction(ctx context.Context, tx *bun.Tx, votes []model.PollVote) error {
+	if _, err := tx.NewInsert().Model(&votes).Exec(ctx); err != nil {
+		return err
+	}
+	optionIds := make([]string, 0, len(votes))
+	for _, vote := range votes {
+		optionIds = append(optionIds, vote.OptionId)
+	}
+	_, err := tx.NewUpdate().Model((*model.PollOption)(nil)).
+		Set("voteCount = voteCount + 1").
+		Where("optionId IN (?)", bun.In(optionIds)).
+		Exec(ctx)
+	return err
+}
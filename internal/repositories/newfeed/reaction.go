@@ -0,0 +1,90 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"program/internal/model"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// reactionColumn is the posts column counting one reaction
+func reactionColumn(reaction model.Reaction) bun.Ident {
+	return bun.Ident("reaction" + strings.ToUpper(string(reaction[:1])) + string(reaction[1:]))
+}
+
+// GetLikeTransaction returns the like row of userId on a post or comment, nil
+// if the user never liked it. The row stays locked until tx ends, so that
+// concurrent toggles apply one after the other.
+func (r *NewsfeedRepo) GetLikeTransaction(ctx context.Context, tx *bun.Tx, likeType model.LikeType, targetId, userId string) (*model.Like, error) {
+	like := new(model.Like)
+	err := tx.NewSelect().
+		Model(like).
+		Where("type = ? AND targetId = ? AND userId = ?", likeType, targetId, userId).
+		For("UPDATE").
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return like, nil
+}
+
+func (r *NewsfeedRepo) SetReactionTransaction(ctx context.Context, tx *bun.Tx, userId, postId string, reaction model.Reaction, active bool) error {
+	_, err := tx.NewUpdate().
+		Model((*model.Like)(nil)).
+		Set("reaction = ?", reaction).
+		Set("isActive = ?", active).
+		Set("updatedAt = ?", time.Now()).
+		Where("userId = ? AND type = ? AND targetId = ?", userId, model.LikePost, postId).
+		Exec(ctx)
+	return err
+}
+
+// UpdateReactionCounts moves one reaction of a post from removed to added,
+// either may be empty when a reaction is only added or only removed. The
+// likeCount total follows.
+func (r *NewsfeedRepo) UpdateReactionCounts(ctx context.Context, tx *bun.Tx, postId string, added, removed model.Reaction) error {
+	query := tx.NewUpdate().Model((*model.Post)(nil)).
+		Where("postId = ?", postId)
+	if added != "" {
+		query.Set("? = ? + 1", reactionColumn(added), reactionColumn(added))
+	}
+	if removed != "" {
+		query.Set("? = GREATEST(? - 1, 0)", reactionColumn(removed), reactionColumn(removed))
+	}
+	switch {
+	case added != "" && removed == "":
+		query.Set("likeCount = likeCount + 1")
+	case added == "" && removed != "":
+		query.Set("likeCount = GREATEST(likeCount - 1, 0)")
+	case added == "" && removed == "":
+		return nil
+	}
+	_, err := query.Exec(ctx)
+	return err
+}
+
+// GetReactions returns the active reactions of userId on the given posts
+func (r *NewsfeedRepo) GetReactions(ctx context.Context, userId string, postIds []string) ([]model.Like, error) {
+	reactions := make([]model.Like, 0)
+	if len(postIds) == 0 {
+		return reactions, nil
+	}
+	err := r.db.GetDB().NewSelect().
+		Model(&reactions).
+		Column("targetId", "reaction").
+		Where("userId = ? AND type = ? AND targetId IN (?) AND isActive = 1", userId, model.LikePost, bun.In(postIds)).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return reactions, nil
+		}
+		return nil, err
+	}
+	return reactions, nil
+}
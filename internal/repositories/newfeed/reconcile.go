@@ -0,0 +1,102 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"program/internal/model"
+
+	"github.com/uptrace/bun"
+)
+
+// counterSource describes how a denormalized counter is recomputed: source
+// selects the actual (targetId, total) pairs from the counted table.
+type counterSource struct {
+	table  string
+	key    string
+	column string
+	source func(db bun.IDB) *bun.SelectQuery
+}
+
+var counterSources = append([]counterSource{
+	{"posts", "postId", "likeCount", func(db bun.IDB) *bun.SelectQuery {
+		return db.NewSelect().ColumnExpr("targetId, COUNT(*) AS total").TableExpr("likes").
+			Where("type = ? AND isActive = 1", model.LikePost).GroupExpr("targetId")
+	}},
+	{"posts", "postId", "commentCount", func(db bun.IDB) *bun.SelectQuery {
+		return db.NewSelect().ColumnExpr("postId AS targetId, COUNT(*) AS total").TableExpr("comments").
+			Where("status = ?", model.ActiveComment).GroupExpr("postId")
+	}},
+	{"posts", "postId", "shareCount", func(db bun.IDB) *bun.SelectQuery {
+		return db.NewSelect().ColumnExpr("sharedPostId AS targetId, COUNT(*) AS total").TableExpr("posts").
+			Where("sharedPostId IS NOT NULL AND deleted = 0").GroupExpr("sharedPostId")
+	}},
+	{"comments", "commentId", "likeCount", func(db bun.IDB) *bun.SelectQuery {
+		return db.NewSelect().ColumnExpr("targetId, COUNT(*) AS total").TableExpr("likes").
+			Where("type = ? AND isActive = 1", model.LikeComment).GroupExpr("targetId")
+	}},
+	{"comments", "commentId", "repliesCount", func(db bun.IDB) *bun.SelectQuery {
+		return db.NewSelect().ColumnExpr("parentId AS targetId, COUNT(*) AS total").TableExpr("comments").
+			Where("parentId IS NOT NULL AND status = ?", model.ActiveComment).GroupExpr("parentId")
+	}},
+	{"poll_options", "optionId", "voteCount", func(db bun.IDB) *bun.SelectQuery {
+		return db.NewSelect().ColumnExpr("optionId AS targetId, COUNT(*) AS total").TableExpr("poll_votes").
+			GroupExpr("optionId")
+	}},
+}, reactionCounterSources()...)
+
+func reactionCounterSources() []counterSource {
+	sources := make([]counterSource, 0, len(model.Reactions))
+	for _, reaction := range model.Reactions {
+		sources = append(sources, counterSource{"posts", "postId", string(reactionColumn(reaction)), func(db bun.IDB) *bun.SelectQuery {
+			return db.NewSelect().ColumnExpr("targetId, COUNT(*) AS total").TableExpr("likes").
+				Where("type = ? AND reaction = ? AND isActive = 1", model.LikePost, reaction).GroupExpr("targetId")
+		}})
+	}
+	return sources
+}
+
+// FindCounterDrift recomputes every denormalized counter and returns the rows
+// whose stored value is off.
+func (r *NewsfeedRepo) FindCounterDrift(ctx context.Context) ([]model.CounterDrift, error) {
+	drift := make([]model.CounterDrift, 0)
+	db := r.db.GetDB()
+	for _, counter := range counterSources {
+		rows := make([]model.CounterDrift, 0)
+		err := db.NewSelect().
+			ColumnExpr("t.? AS id", bun.Ident(counter.key)).
+			ColumnExpr("t.? AS stored", bun.Ident(counter.column)).
+			ColumnExpr("COALESCE(s.total, 0) AS actual").
+			TableExpr("? AS t", bun.Ident(counter.table)).
+			Join("LEFT JOIN (?) AS s ON s.targetId = t.?", counter.source(db), bun.Ident(counter.key)).
+			Where("t.? != COALESCE(s.total, 0)", bun.Ident(counter.column)).
+			Scan(ctx, &rows)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		for i := range rows {
+			rows[i].Table = counter.table
+			rows[i].Column = counter.column
+		}
+		drift = append(drift, rows...)
+	}
+	return drift, nil
+}
+
+// FixCounterDrift overwrites the drifted counters with their actual values
+func (r *NewsfeedRepo) FixCounterDrift(ctx context.Context, tx *bun.Tx, drift []model.CounterDrift) error {
+	keys := make(map[string]string, len(counterSources))
+	for _, counter := range counterSources {
+		keys[counter.table] = counter.key
+	}
+	for _, d := range drift {
+		_, err := tx.NewUpdate().
+			TableExpr("?", bun.Ident(d.Table)).
+			Set("? = ?", bun.Ident(d.Column), d.Actual).
+			Where("? = ?", bun.Ident(keys[d.Table]), d.Id).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package newsfeedRepo
+
+import (
+	"context"
+	"database/sql"
+	"program/internal/model"
+
+	"github.com/uptrace/bun"
+)
+
+func (r *NewsfeedRepo) CreatePostRevision(ctx context.Context, tx *bun.Tx, revision *model.PostRevision) error {
+	_, err := tx.NewInsert().
+		Model(revision).
+		Exec(ctx)
+	return err
+}
+
+// GetPostRevisions returns the previous versions of a post, newest first
+func (r *NewsfeedRepo) GetPostRevisions(ctx context.Context, postId string) ([]model.PostRevision, error) {
+	revisions := make([]model.PostRevision, 0)
+	err := r.db.GetDB().NewSelect().
+		Model(&revisions).
+		Where("postId = ?", postId).
+		OrderExpr("createdAt DESC").
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return revisions, nil
+		}
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *NewsfeedRepo) CreateCommentRevision(ctx context.Context, tx *bun.Tx, revision *model.CommentRevision) error {
+	_, err := tx.NewInsert().
+		Model(revision).
+		Exec(ctx)
+	return err
+}
+
+// GetCommentRevisions returns the previous versions of a comment, newest first
+func (r *NewsfeedRepo) GetCommentRevisions(ctx context.Context, commentId string) ([]model.CommentRevision, error) {
+	revisions := make([]model.CommentRevision, 0)
+	err := r.db.GetDB().NewSelect().
+		Model(&revisions).
+		Where("commentId = ?", commentId).
+		OrderExpr("createdAt DESC").
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return revisions, nil
+		}
+		return nil, err
+	}
+	return revisions, nil
+}
@@ -0,0 +1,70 @@
+package notificationRepo
+
+import (
+	"context"
+	"database/sql"
+	"program/internal/database"
+	"program/internal/model"
+
+	"github.com/uptrace/bun"
+)
+
+type NotificationRepo struct {
+	db database.ISqlConnection
+}
+
+func NewNotificationRepo(db database.ISqlConnection) INotificationRepo {
+	return &NotificationRepo{db: db}
+}
+
+func (r *NotificationRepo) CreateNotifications(ctx context.Context, notifications []model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	_, err := r.db.GetDB().NewInsert().
+		Model(&notifications).
+		Exec(ctx)
+	return err
+}
+
+func (r *NotificationRepo) GetNotifications(ctx context.Context, limit, offset int, userId string) (*[]model.NotificationInfo, error) {
+	notifications := new([]model.NotificationInfo)
+	query := r.db.GetDB().NewSelect().
+		Column("n.notificationId", "n.actorId", "p.firstname", "p.lastname", "p.avatarUrl", "n.type", "n.postId", "n.commentId", "n.isRead", "n.createdAt").
+		TableExpr("notifications as n").
+		Join("LEFT JOIN profiles p ON p.userId = n.actorId").
+		Where("n.userId = ?", userId).
+		OrderExpr("n.createdAt DESC")
+	if limit > 0 {
+		query.Limit(limit).Offset(offset)
+	}
+	err := query.Scan(ctx, notifications)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return notifications, nil
+		}
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *NotificationRepo) CountUnread(ctx context.Context, userId string) (int, error) {
+	return r.db.GetDB().NewSelect().
+		Model((*model.Notification)(nil)).
+		Where("userId = ? AND isRead = 0", userId).
+		Count(ctx)
+}
+
+// MarkRead marks the given notifications as read, or all of them when no id
+// is given.
+func (r *NotificationRepo) MarkRead(ctx context.Context, userId string, notificationIds []string) error {
+	query := r.db.GetDB().NewUpdate().
+		Model((*model.Notification)(nil)).
+		Set("isRead = 1").
+		Where("userId = ? AND isRead = 0", userId)
+	if len(notificationIds) > 0 {
+		query.Where("notificationId IN (?)", bun.In(notificationIds))
+	}
+	_, err := query.Exec(ctx)
+	return err
+}
@@ -0,0 +1,13 @@
+package notificationRepo
+
+import (
+	"context"
+	"program/internal/model"
+)
+
+type INotificationRepo interface {
+	CreateNotifications(ctx context.Context, notifications []model.Notification) error
+	GetNotifications(ctx context.Context, limit, offset int, userId string) (*[]model.NotificationInfo, error)
+	CountUnread(ctx context.Context, userId string) (int, error)
+	MarkRead(ctx context.Context, userId string, notificationIds []string) error
+}
@@ -0,0 +1,128 @@
+package relationshipsRepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"program/internal/model"
+
+	"github.com/uptrace/bun"
+)
+
+func (r *RelationshipsRepo) CreateAudienceList(ctx context.Context, list *model.AudienceList) error {
+	_, err := r.db.GetDB().NewInsert().
+		Model(list).
+		Exec(ctx)
+	return err
+}
+
+// GetAudienceLists returns the lists of a user with their member count
+func (r *RelationshipsRepo) GetAudienceLists(ctx context.Context, ownerId string) ([]model.AudienceList, error) {
+	lists := make([]model.AudienceList, 0)
+	err := r.db.GetDB().NewSelect().
+		Model(&lists).
+		ColumnExpr("al.*").
+		ColumnExpr("(SELECT COUNT(*) FROM audience_members am WHERE am.listId = al.listId) AS memberCount").
+		Where("al.ownerId = ?", ownerId).
+		OrderExpr("al.name ASC").
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return lists, nil
+		}
+		return nil, err
+	}
+	return lists, nil
+}
+
+func (r *RelationshipsRepo) GetAudienceList(ctx context.Context, listId string) (*model.AudienceList, error) {
+	list := new(model.AudienceList)
+	err := r.db.GetDB().NewSelect().
+		Model(list).
+		Where("listId = ?", listId).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// DeleteAudienceListTransaction removes a list with its members and its links
+// to posts. Custom posts left without list are only seen by their author.
+func (r *RelationshipsRepo) DeleteAudienceListTransaction(ctx context.Context, tx *bun.Tx, listId string) error {
+	if _, err := tx.NewDelete().Model((*model.PostAudience)(nil)).Where("listId = ?", listId).Exec(ctx); err != nil {
+		return err
+	}
+	if _, err := tx.NewDelete().Model((*model.AudienceMember)(nil)).Where("listId = ?", listId).Exec(ctx); err != nil {
+		return err
+	}
+	resp, err := tx.NewDelete().Model((*model.AudienceList)(nil)).Where("listId = ?", listId).Exec(ctx)
+	if err != nil {
+		return err
+	} else if affected, _ := resp.RowsAffected(); affected < 1 {
+		return errors.New("this list was not found")
+	}
+	return nil
+}
+
+func (r *RelationshipsRepo) GetAudienceMemberIds(ctx context.Context, listId string) ([]string, error) {
+	memberIds := make([]string, 0)
+	err := r.db.GetDB().NewSelect().
+		Model((*model.AudienceMember)(nil)).
+		Column("memberId").
+		Where("listId = ?", listId).
+		Scan(ctx, &memberIds)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return memberIds, nil
+		}
+		return nil, err
+	}
+	return memberIds, nil
+}
+
+func (r *RelationshipsRepo) GetAudienceMembers(ctx context.Context, limit, offset int, listId string) (int, []model.AudienceMemberInfo, error) {
+	members := make([]model.AudienceMemberInfo, 0)
+	query := r.db.GetDB().NewSelect().
+		Column("p.userId", "p.profileId", "p.firstname", "p.lastname", "p.avatarUrl").
+		TableExpr("audience_members as am").
+		Join("JOIN profiles p ON p.userId = am.memberId").
+		Where("am.listId = ?", listId).
+		Order("p.lastname ASC")
+	if limit > 0 {
+		query.Limit(limit).Offset(offset)
+	}
+	total, err := query.ScanAndCount(ctx, &members)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, members, nil
+		}
+		return 0, nil, err
+	}
+	return total, members, nil
+}
+
+// CountExistingUsers counts how many of userIds belong to a user
+func (r *RelationshipsRepo) CountExistingUsers(ctx context.Context, userIds []string) (int, error) {
+	return r.db.GetDB().NewSelect().
+		Model((*model.User)(nil)).
+		Where("id IN (?)", bun.In(userIds)).
+		Count(ctx)
+}
+
+// AddAudienceMembers adds users to a list, ignoring those already in it
+func (r *RelationshipsRepo) AddAudienceMembers(ctx context.Context, members []model.AudienceMember) error {
+	_, err := r.db.GetDB().NewInsert().
+		Model(&members).
+		Ignore().
+		Exec(ctx)
+	return err
+}
+
+func (r *RelationshipsRepo) RemoveAudienceMembers(ctx context.Context, listId string, memberIds []string) error {
+	_, err := r.db.GetDB().NewDelete().
+		Model((*model.AudienceMember)(nil)).
+		Where("listId = ? AND memberId IN (?)", listId, bun.In(memberIds)).
+		Exec(ctx)
+	return err
+}
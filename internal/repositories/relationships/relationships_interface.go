@@ -16,4 +16,15 @@ type IRelationshipsRepo interface {
 	UpdateMutualFollowStatusTransaction(ctx context.Context, tx *bun.Tx, followerId, followingId string, status bool) error
 	GetFollowList(ctx context.Context, limit, offset int, targetUserId string, isFollowingUser bool) (int, *[]model.FollowerInfo, error)
 	NumOfFollowRelationship(ctx context.Context, targetUserId string) (int, int, error)
+
+	//Audience lists
+	CreateAudienceList(ctx context.Context, list *model.AudienceList) error
+	GetAudienceLists(ctx context.Context, ownerId string) ([]model.AudienceList, error)
+	GetAudienceList(ctx context.Context, listId string) (*model.AudienceList, error)
+	DeleteAudienceListTransaction(ctx context.Context, tx *bun.Tx, listId string) error
+	GetAudienceMemberIds(ctx context.Context, listId string) ([]string, error)
+	GetAudienceMembers(ctx context.Context, limit, offset int, listId string) (int, []model.AudienceMemberInfo, error)
+	CountExistingUsers(ctx context.Context, userIds []string) (int, error)
+	AddAudienceMembers(ctx context.Context, members []model.AudienceMember) error
+	RemoveAudienceMembers(ctx context.Context, listId string, memberIds []string) error
 }
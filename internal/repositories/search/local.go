@@ -0,0 +1,294 @@
+package searchRepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"program/internal/database"
+	"program/internal/model"
+	newsfeedRepo "program/internal/repositories/newfeed"
+	"program/internal/textnorm"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/whitespace"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/uptrace/bun"
+)
+
+// Upper bound of hits read from an index at once
+const maxCandidates = 1000
+
+// Documents are written in batches of this size while warming
+const warmBatchSize = 1000
+
+// LocalSearchRepo is an embedded in-memory Bleve index. It is warmed from
+// MySQL at startup and kept up to date through the Index methods; privacy is
+// still checked against MySQL for every query.
+type LocalSearchRepo struct {
+	db     database.ISqlConnection
+	posts  bleve.Index
+	people bleve.Index
+}
+
+func NewLocalSearchRepo(db database.ISqlConnection) (*LocalSearchRepo, error) {
+	posts, err := newIndex()
+	if err != nil {
+		return nil, err
+	}
+	people, err := newIndex()
+	if err != nil {
+		return nil, err
+	}
+	return &LocalSearchRepo{
+		db:     db,
+		posts:  posts,
+		people: people,
+	}, nil
+}
+
+// searchDocument is what the index keeps of a post or a person: its terms,
+// already folded by textnorm so that matching agrees with the highlighting,
+// and its creation time zero padded to sort as text.
+type searchDocument struct {
+	Terms   string `json:"terms"`
+	Created string `json:"created"`
+}
+
+func newSearchDocument(tokens []string, createdAt int64) searchDocument {
+	return searchDocument{
+		Terms:   strings.Join(tokens, " "),
+		Created: fmt.Sprintf("%020d", createdAt),
+	}
+}
+
+func personTokens(doc model.SearchDocument) []string {
+	return append(textnorm.Tokenize(doc.Text), textnorm.Fold(doc.Username))
+}
+
+func newIndex() (bleve.Index, error) {
+	mapping := bleve.NewIndexMapping()
+	err := mapping.AddCustomAnalyzer("folded", map[string]any{
+		"type":      custom.Name,
+		"tokenizer": whitespace.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	terms := bleve.NewTextFieldMapping()
+	terms.Analyzer = "folded"
+	terms.Store = false
+	terms.IncludeInAll = false
+	created := bleve.NewKeywordFieldMapping()
+	created.Store = false
+	created.IncludeInAll = false
+
+	document := bleve.NewDocumentStaticMapping()
+	document.AddFieldMappingsAt("terms", terms)
+	document.AddFieldMappingsAt("created", created)
+	mapping.DefaultMapping = document
+	return bleve.NewMemOnly(mapping)
+}
+
+// Warm loads every post and profile into the index
+func (r *LocalSearchRepo) Warm(ctx context.Context) error {
+	posts := make([]model.SearchDocument, 0)
+	err := r.db.GetDB().NewSelect().
+		ColumnExpr("p.postId AS id").
+		ColumnExpr("p.content AS text").
+		ColumnExpr("UNIX_TIMESTAMP(p.createdAt) AS createdAt").
+		TableExpr("posts as p").
+		Where("p.deleted = 0 AND p.status = ?", model.PublishedPost).
+		Scan(ctx, &posts)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	people, err := r.loadPeople(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := warm(r.posts, posts, func(doc model.SearchDocument) []string { return textnorm.Tokenize(doc.Text) }); err != nil {
+		return err
+	}
+	return warm(r.people, people, personTokens)
+}
+
+func warm(index bleve.Index, docs []model.SearchDocument, tokens func(model.SearchDocument) []string) error {
+	batch := index.NewBatch()
+	for _, doc := range docs {
+		if err := batch.Index(doc.Id, newSearchDocument(tokens(doc), doc.CreatedAt)); err != nil {
+			return err
+		}
+		if batch.Size() >= warmBatchSize {
+			if err := index.Batch(batch); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return index.Batch(batch)
+}
+
+func (r *LocalSearchRepo) loadPeople(ctx context.Context, userIds []string) ([]model.SearchDocument, error) {
+	people := make([]model.SearchDocument, 0)
+	query := r.db.GetDB().NewSelect().
+		ColumnExpr("a.id AS id").
+		ColumnExpr("CONCAT(pf.firstname, ' ', pf.lastname) AS text").
+		Column("a.username").
+		ColumnExpr("UNIX_TIMESTAMP(a.createdAt) AS createdAt").
+		TableExpr("accounts as a").
+		Join("JOIN profiles pf ON pf.userId = a.id").
+		Where("a.deleted = 0")
+	if userIds != nil {
+		query.Where("a.id IN (?)", bun.In(userIds))
+	}
+	err := query.Scan(ctx, &people)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return people, nil
+}
+
+func (r *LocalSearchRepo) IndexPost(ctx context.Context, post *model.Post) error {
+	return r.posts.Index(post.PostId, newSearchDocument(textnorm.Tokenize(post.Content), post.CreatedAt.Unix()))
+}
+
+func (r *LocalSearchRepo) RemovePost(ctx context.Context, postId string) error {
+	return r.posts.Delete(postId)
+}
+
+func (r *LocalSearchRepo) IndexProfile(ctx context.Context, userId string) error {
+	people, err := r.loadPeople(ctx, []string{userId})
+	if err != nil {
+		return err
+	}
+	if len(people) == 0 {
+		return r.people.Delete(userId)
+	}
+	return r.people.Index(userId, newSearchDocument(personTokens(people[0]), people[0].CreatedAt))
+}
+
+func (r *LocalSearchRepo) SearchPosts(ctx context.Context, userId, query string, limit int, key *model.SearchKey) ([]model.SearchKey, error) {
+	return search(ctx, r.posts, textnorm.Tokenize(query), limit, key, func(postIds []string) (map[string]bool, error) {
+		visible := make([]string, 0)
+		q := r.db.GetDB().NewSelect().
+			Column("p.postId").
+			TableExpr("posts as p").
+			Where("p.postId IN (?)", bun.In(postIds))
+		err := newsfeedRepo.VisibleTo(q, userId).Scan(ctx, &visible)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		visibleSet := make(map[string]bool, len(visible))
+		for _, postId := range visible {
+			visibleSet[postId] = true
+		}
+		return visibleSet, nil
+	})
+}
+
+func (r *LocalSearchRepo) SearchPeople(ctx context.Context, userId, query string, limit int, key *model.SearchKey) ([]model.PersonResult, error) {
+	byId := make(map[string]model.PersonResult)
+	hits, err := search(ctx, r.people, textnorm.Tokenize(query), limit, key, func(userIds []string) (map[string]bool, error) {
+		found := make([]model.PersonResult, 0)
+		q := r.db.GetDB().NewSelect().
+			ColumnExpr("a.id AS userId").
+			Column("a.username", "pf.profileId", "pf.firstname", "pf.lastname", "pf.avatarUrl").
+			TableExpr("accounts as a").
+			Join("JOIN profiles pf ON pf.userId = a.id").
+			Where("a.id IN (?)", bun.In(userIds))
+		err := peopleVisibleTo(q, userId).Scan(ctx, &found)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		visibleSet := make(map[string]bool, len(found))
+		for _, person := range found {
+			byId[person.UserId] = person
+			visibleSet[person.UserId] = true
+		}
+		return visibleSet, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	people := make([]model.PersonResult, 0, len(hits))
+	for _, hit := range hits {
+		person := byId[hit.Id]
+		person.Score = hit.Score
+		person.CreatedAt = hit.CreatedAt
+		people = append(people, person)
+	}
+	return people, nil
+}
+
+// search reads the ranking of the documents matching tokens after key, batch
+// by batch, keeping the ones visible accepts until limit are found or the
+// index has no more matches.
+func search(ctx context.Context, index bleve.Index, tokens []string, limit int, key *model.SearchKey, visible func(ids []string) (map[string]bool, error)) ([]model.SearchKey, error) {
+	hits := make([]model.SearchKey, 0, limit)
+	if len(tokens) == 0 || limit <= 0 {
+		return hits, nil
+	}
+	size := min(max(2*limit, 20), maxCandidates)
+	for {
+		request := bleve.NewSearchRequestOptions(matchAll(tokens), size, 0, false)
+		request.SortBy([]string{"-_score", "-created", "-_id"})
+		if key != nil {
+			request.SearchAfter = []string{
+				strconv.FormatFloat(key.Score, 'g', -1, 64),
+				fmt.Sprintf("%020d", key.CreatedAt.Unix()),
+				key.Id,
+			}
+		}
+		result, err := index.SearchInContext(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Hits) == 0 {
+			return hits, nil
+		}
+		ids := make([]string, 0, len(result.Hits))
+		for _, hit := range result.Hits {
+			ids = append(ids, hit.ID)
+		}
+		allowed, err := visible(ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range result.Hits {
+			created, _ := strconv.ParseInt(hit.Sort[1], 10, 64)
+			key = &model.SearchKey{Score: hit.Score, CreatedAt: time.Unix(created, 0), Id: hit.ID}
+			if !allowed[hit.ID] {
+				continue
+			}
+			hits = append(hits, *key)
+			if len(hits) == limit {
+				return hits, nil
+			}
+		}
+		if len(result.Hits) < size {
+			return hits, nil
+		}
+	}
+}
+
+// matchAll matches the documents containing every token, the last one as a
+// prefix so that partially typed words match, scored by tf-idf.
+func matchAll(tokens []string) query.Query {
+	conjuncts := make([]query.Query, 0, len(tokens))
+	for i, token := range tokens {
+		if i == len(tokens)-1 {
+			prefix := bleve.NewPrefixQuery(token)
+			prefix.SetField("terms")
+			conjuncts = append(conjuncts, prefix)
+			continue
+		}
+		term := bleve.NewTermQuery(token)
+		term.SetField("terms")
+		conjuncts = append(conjuncts, term)
+	}
+	return bleve.NewConjunctionQuery(conjuncts...)
+}
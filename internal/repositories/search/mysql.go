@@ -0,0 +1,138 @@
+package searchRepo
+
+import (
+	"context"
+	"database/sql"
+	"program/internal/database"
+	"program/internal/model"
+	newsfeedRepo "program/internal/repositories/newfeed"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// exactUsernameBoost ranks the account whose username is the query above any
+// name match, whose relevance stays far below it
+const exactUsernameBoost = 1000
+
+// fullTextIndexes are the FULLTEXT indexes MySqlSearchRepo relies on
+var fullTextIndexes = []struct {
+	table   string
+	name    string
+	columns string
+}{
+	{table: "posts", name: "ft_posts_content", columns: "content"},
+	{table: "profiles", name: "ft_profiles_name", columns: "firstname,lastname"},
+}
+
+// MySqlSearchRepo searches with MySQL FULLTEXT indexes, created by
+// EnsureIndexes when they are missing
+type MySqlSearchRepo struct {
+	db database.ISqlConnection
+}
+
+func NewMySqlSearchRepo(db database.ISqlConnection) *MySqlSearchRepo {
+	return &MySqlSearchRepo{db: db}
+}
+
+// EnsureIndexes creates the FULLTEXT indexes that do not exist yet. An index
+// over the same columns under another name counts as existing.
+func (r *MySqlSearchRepo) EnsureIndexes(ctx context.Context) error {
+	for _, index := range fullTextIndexes {
+		exists, err := r.db.GetDB().NewSelect().
+			ColumnExpr("index_name").
+			TableExpr("information_schema.statistics").
+			Where("table_schema = DATABASE() AND table_name = ? AND index_type = 'FULLTEXT'", index.table).
+			GroupExpr("index_name").
+			Having("GROUP_CONCAT(column_name ORDER BY seq_in_index) = ?", index.columns).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		_, err = r.db.GetDB().ExecContext(ctx, "ALTER TABLE ? ADD FULLTEXT INDEX ? (?)",
+			bun.Ident(index.table), bun.Ident(index.name), bun.Safe(index.columns))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// peopleVisibleTo keeps the accounts a viewer can find: deleted and suspended
+// accounts are left out, and private profiles are only found by their owner.
+func peopleVisibleTo(query *bun.SelectQuery, viewerId string) *bun.SelectQuery {
+	return query.
+		Where("a.deleted = 0").
+		Where("NOT EXISTS (SELECT 1 FROM suspensions s WHERE s.userId = a.id AND s.until > NOW())").
+		Where("(pf.private = 0 OR a.id = ?)", viewerId)
+}
+
+// resumeAfter resumes a ranking of hits after a key, idColumn is the hit's id
+func resumeAfter(query *bun.SelectQuery, idColumn string, key *model.SearchKey) *bun.SelectQuery {
+	if key == nil {
+		return query
+	}
+	return query.Where("(hit.score < ? OR (hit.score = ? AND (hit.createdAt < ? OR (hit.createdAt = ? AND ? < ?))))",
+		key.Score, key.Score, key.CreatedAt, key.CreatedAt, bun.Ident("hit."+idColumn), key.Id)
+}
+
+func (r *MySqlSearchRepo) SearchPosts(ctx context.Context, userId, query string, limit int, key *model.SearchKey) ([]model.SearchKey, error) {
+	hits := make([]model.SearchKey, 0)
+	matches := r.db.GetDB().NewSelect().
+		ColumnExpr("p.postId AS id").
+		Column("p.createdAt").
+		ColumnExpr("MATCH(p.content) AGAINST (? IN NATURAL LANGUAGE MODE) AS score", query).
+		TableExpr("posts as p").
+		Where("MATCH(p.content) AGAINST (? IN NATURAL LANGUAGE MODE)", query)
+	q := r.db.GetDB().NewSelect().
+		TableExpr("(?) AS hit", newsfeedRepo.VisibleTo(matches, userId)).
+		OrderExpr("hit.score DESC, hit.createdAt DESC, hit.id DESC").
+		Limit(limit)
+	err := resumeAfter(q, "id", key).Scan(ctx, &hits)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return hits, nil
+		}
+		return nil, err
+	}
+	return hits, nil
+}
+
+func (r *MySqlSearchRepo) SearchPeople(ctx context.Context, userId, query string, limit int, key *model.SearchKey) ([]model.PersonResult, error) {
+	people := make([]model.PersonResult, 0)
+	prefix := strings.NewReplacer("%", "\\%", "_", "\\_").Replace(query) + "%"
+	matches := r.db.GetDB().NewSelect().
+		ColumnExpr("a.id AS userId").
+		Column("a.username", "a.createdAt", "pf.profileId", "pf.firstname", "pf.lastname", "pf.avatarUrl").
+		ColumnExpr("MATCH(pf.firstname, pf.lastname) AGAINST (? IN NATURAL LANGUAGE MODE) + (a.username = ?) * ? AS score", query, query, exactUsernameBoost).
+		TableExpr("accounts as a").
+		Join("JOIN profiles pf ON pf.userId = a.id").
+		Where("(MATCH(pf.firstname, pf.lastname) AGAINST (? IN NATURAL LANGUAGE MODE) OR a.username LIKE ?)", query, prefix)
+	q := r.db.GetDB().NewSelect().
+		TableExpr("(?) AS hit", peopleVisibleTo(matches, userId)).
+		OrderExpr("hit.score DESC, hit.createdAt DESC, hit.userId DESC").
+		Limit(limit)
+	err := resumeAfter(q, "userId", key).Scan(ctx, &people)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return people, nil
+		}
+		return nil, err
+	}
+	return people, nil
+}
+
+func (r *MySqlSearchRepo) IndexPost(ctx context.Context, post *model.Post) error {
+	return nil
+}
+
+func (r *MySqlSearchRepo) RemovePost(ctx context.Context, postId string) error {
+	return nil
+}
+
+func (r *MySqlSearchRepo) IndexProfile(ctx context.Context, userId string) error {
+	return nil
+}
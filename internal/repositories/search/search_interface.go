@@ -0,0 +1,19 @@
+package searchRepo
+
+import (
+	"context"
+	"program/internal/model"
+)
+
+// ISearchRepo is a search backend. Results are ranked by relevance and
+// already filtered for the viewer; a page starts after the key of the last
+// result of the previous one, the first page after nil.
+type ISearchRepo interface {
+	SearchPosts(ctx context.Context, userId, query string, limit int, after *model.SearchKey) ([]model.SearchKey, error)
+	SearchPeople(ctx context.Context, userId, query string, limit int, after *model.SearchKey) ([]model.PersonResult, error)
+
+	// Index maintenance, a no-op for backends reading the tables directly
+	IndexPost(ctx context.Context, post *model.Post) error
+	RemovePost(ctx context.Context, postId string) error
+	IndexProfile(ctx context.Context, userId string) error
+}
@@ -0,0 +1,227 @@
+package timelineRepo
+
+import (
+	"context"
+	"encoding/json"
+	"program/internal/database"
+	"program/internal/model"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Number of users written per Redis pipeline when fanning out a post
+const fanOutBatchSize = 500
+
+type TimelineRepo struct {
+	rdb database.IRedisConnection
+}
+
+func NewTimelineRepo(rdb database.IRedisConnection) ITimelineRepo {
+	return &TimelineRepo{
+		rdb: rdb,
+	}
+}
+
+func timelineKey(userId string) string {
+	return "timeline:user:" + userId
+}
+
+func feedPageKey(userId, page string) string {
+	return "newsfeed:user:" + userId + ":page:" + page
+}
+
+// feedPagesKey holds the set of cached page keys of a user so that they can
+// be dropped together.
+func feedPagesKey(userId string) string {
+	return "newsfeed:user:" + userId + ":pages"
+}
+
+func postCacheKey(postId string) string {
+	return "newsfeed:post:" + postId
+}
+
+func (r *TimelineRepo) TimelineExists(ctx context.Context, userId string) (bool, error) {
+	res, err := r.rdb.GetDB().Exists(ctx, timelineKey(userId)).Result()
+	if err != nil {
+		return false, err
+	}
+	return res > 0, nil
+}
+
+// PushToTimelines adds entries to every user's timeline and trims each one
+// to the newest maxLength posts.
+func (r *TimelineRepo) PushToTimelines(ctx context.Context, userIds []string, entries []model.TimelineEntry, maxLength int) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	members := make([]redis.Z, 0, len(entries))
+	for _, entry := range entries {
+		members = append(members, redis.Z{
+			Score:  float64(entry.CreatedAt.UnixMilli()),
+			Member: entry.PostId,
+		})
+	}
+	for start := 0; start < len(userIds); start += fanOutBatchSize {
+		end := min(start+fanOutBatchSize, len(userIds))
+		pipe := r.rdb.GetDB().Pipeline()
+		for _, userId := range userIds[start:end] {
+			key := timelineKey(userId)
+			pipe.ZAdd(ctx, key, members...)
+			if maxLength > 0 {
+				pipe.ZRemRangeByRank(ctx, key, 0, int64(-maxLength-1))
+			}
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TimelineRepo) GetTimeline(ctx context.Context, userId string, start, stop int64) ([]model.TimelineEntry, error) {
+	res, err := r.rdb.GetDB().ZRevRangeWithScores(ctx, timelineKey(userId), start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	return toTimelineEntries(res), nil
+}
+
+func (r *TimelineRepo) GetOldestEntry(ctx context.Context, userId string) (*model.TimelineEntry, error) {
+	res, err := r.rdb.GetDB().ZRangeWithScores(ctx, timelineKey(userId), 0, 0).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return &toTimelineEntries(res)[0], nil
+}
+
+func (r *TimelineRepo) RemoveFromTimeline(ctx context.Context, userId string, postIds []string) error {
+	if len(postIds) == 0 {
+		return nil
+	}
+	members := make([]any, 0, len(postIds))
+	for _, postId := range postIds {
+		members = append(members, postId)
+	}
+	_, err := r.rdb.GetDB().ZRem(ctx, timelineKey(userId), members...).Result()
+	return err
+}
+
+func toTimelineEntries(res []redis.Z) []model.TimelineEntry {
+	entries := make([]model.TimelineEntry, 0, len(res))
+	for _, z := range res {
+		postId, ok := z.Member.(string)
+		if !ok {
+			continue
+		}
+		entries = append(entries, model.TimelineEntry{
+			PostId:    postId,
+			CreatedAt: time.UnixMilli(int64(z.Score)),
+		})
+	}
+	return entries
+}
+
+// Cache Redis
+func (r *TimelineRepo) GetFeedPage(ctx context.Context, userId, page string) ([]string, bool, error) {
+	data, err := r.rdb.GetDB().Get(ctx, feedPageKey(userId, page)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	postIds := make([]string, 0)
+	if err := json.Unmarshal(data, &postIds); err != nil {
+		return nil, false, err
+	}
+	return postIds, true, nil
+}
+
+func (r *TimelineRepo) SaveFeedPage(ctx context.Context, userId, page string, postIds []string, ttl time.Duration) error {
+	data, err := json.Marshal(postIds)
+	if err != nil {
+		return err
+	}
+	key := feedPageKey(userId, page)
+	pipe := r.rdb.GetDB().TxPipeline()
+	pipe.Set(ctx, key, data, ttl)
+	pipe.SAdd(ctx, feedPagesKey(userId), key)
+	pipe.Expire(ctx, feedPagesKey(userId), ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *TimelineRepo) InvalidateFeedPages(ctx context.Context, userIds []string) error {
+	for start := 0; start < len(userIds); start += fanOutBatchSize {
+		end := min(start+fanOutBatchSize, len(userIds))
+		pipe := r.rdb.GetDB().Pipeline()
+		members := make([]*redis.StringSliceCmd, 0, end-start)
+		for _, userId := range userIds[start:end] {
+			members = append(members, pipe.SMembers(ctx, feedPagesKey(userId)))
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return err
+		}
+		keys := make([]string, 0)
+		for i, userId := range userIds[start:end] {
+			keys = append(keys, members[i].Val()...)
+			keys = append(keys, feedPagesKey(userId))
+		}
+		if _, err := r.rdb.GetDB().Del(ctx, keys...).Result(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TimelineRepo) GetCachedPosts(ctx context.Context, postIds []string) (map[string]model.NewsFeed, error) {
+	posts := make(map[string]model.NewsFeed, len(postIds))
+	if len(postIds) == 0 {
+		return posts, nil
+	}
+	keys := make([]string, 0, len(postIds))
+	for _, postId := range postIds {
+		keys = append(keys, postCacheKey(postId))
+	}
+	values, err := r.rdb.GetDB().MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var post model.NewsFeed
+		if err := json.Unmarshal([]byte(data), &post); err != nil {
+			continue
+		}
+		posts[post.PostId] = post
+	}
+	return posts, nil
+}
+
+func (r *TimelineRepo) SaveCachedPosts(ctx context.Context, posts []model.NewsFeed, ttl time.Duration) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	pipe := r.rdb.GetDB().Pipeline()
+	for _, post := range posts {
+		data, err := json.Marshal(post)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, postCacheKey(post.PostId), data, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *TimelineRepo) DeleteCachedPost(ctx context.Context, postId string) error {
+	_, err := r.rdb.GetDB().Del(ctx, postCacheKey(postId)).Result()
+	return err
+}
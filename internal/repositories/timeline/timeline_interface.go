@@ -0,0 +1,24 @@
+package timelineRepo
+
+import (
+	"context"
+	"program/internal/model"
+	"time"
+)
+
+type ITimelineRepo interface {
+	//Redis
+	TimelineExists(ctx context.Context, userId string) (bool, error)
+	PushToTimelines(ctx context.Context, userIds []string, entries []model.TimelineEntry, maxLength int) error
+	GetTimeline(ctx context.Context, userId string, start, stop int64) ([]model.TimelineEntry, error)
+	GetOldestEntry(ctx context.Context, userId string) (*model.TimelineEntry, error)
+	RemoveFromTimeline(ctx context.Context, userId string, postIds []string) error
+
+	//Redis Cache
+	GetFeedPage(ctx context.Context, userId, page string) ([]string, bool, error)
+	SaveFeedPage(ctx context.Context, userId, page string, postIds []string, ttl time.Duration) error
+	InvalidateFeedPages(ctx context.Context, userIds []string) error
+	GetCachedPosts(ctx context.Context, postIds []string) (map[string]model.NewsFeed, error)
+	SaveCachedPosts(ctx context.Context, posts []model.NewsFeed, ttl time.Duration) error
+	DeleteCachedPost(ctx context.Context, postId string) error
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"program/internal/model"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxAudienceLists bounds the lists a custom post can target
+const maxAudienceLists = 10
+
+// checkAudience validates the audience lists of a post and returns them
+// without duplicates. Only custom posts have lists and they need at least
+// one, owned by the author.
+func (s *NewsfeedService) checkAudience(ctx context.Context, userId string, privacy model.Privacy, listIds []string) ([]string, error) {
+	if privacy != model.Custom {
+		if len(listIds) > 0 {
+			return nil, errors.New("audience lists can only be used with the custom privacy")
+		}
+		return nil, nil
+	}
+	listIds = slices.Compact(slices.Sorted(slices.Values(listIds)))
+	if len(listIds) == 0 || listIds[0] == "" {
+		return nil, errors.New("a custom post needs at least one audience list")
+	}
+	if len(listIds) > maxAudienceLists {
+		return nil, errors.New("a post can target at most 10 audience lists")
+	}
+	owned, err := s.repo.CountOwnedAudienceLists(ctx, userId, listIds)
+	if err != nil {
+		return nil, err
+	}
+	if owned != len(listIds) {
+		return nil, errors.New("audience list was not found")
+	}
+	return listIds, nil
+}
+
+// checkVisible fails with a not found error when userId can not see the post,
+// so that hidden posts can not be told apart from missing ones.
+func (s *NewsfeedService) checkVisible(ctx context.Context, userId, postId string) error {
+	if err := s.repo.CheckFriendPrivacyPermission(ctx, userId, postId); err != nil {
+		return errors.New("this post was not found")
+	}
+	return nil
+}
+
+func (s *RelationshipsService) CreateAudienceList(ctx context.Context, userId string, request *model.AudienceListPost) (any, error) {
+	list := &model.AudienceList{
+		ListId:    uuid.NewString(),
+		OwnerId:   userId,
+		Name:      request.Name,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateAudienceList(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *RelationshipsService) GetAudienceLists(ctx context.Context, userId string) (any, error) {
+	return s.repo.GetAudienceLists(ctx, userId)
+}
+
+// DeleteAudienceList removes a list. Its members lose access to the custom
+// posts that only targeted it.
+func (s *RelationshipsService) DeleteAudienceList(ctx context.Context, userId, listId string) error {
+	if _, err := s.ownedList(ctx, userId, listId); err != nil {
+		return err
+	}
+	memberIds, err := s.repo.GetAudienceMemberIds(ctx, listId)
+	if err != nil {
+		return err
+	}
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = s.repo.DeleteAudienceListTransaction(ctx, tx, listId); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	s.refreshAudience(ctx, userId, memberIds)
+	return nil
+}
+
+func (s *RelationshipsService) GetAudienceMembers(ctx context.Context, limit, offset int, userId, listId string) (any, error) {
+	if _, err := s.ownedList(ctx, userId, listId); err != nil {
+		return nil, err
+	}
+	total, members, err := s.repo.GetAudienceMembers(ctx, limit, offset, listId)
+	if err != nil {
+		return nil, err
+	}
+	return &map[string]any{
+		"data":   members,
+		"limit":  limit,
+		"offset": offset,
+		"total":  total,
+	}, nil
+}
+
+func (s *RelationshipsService) AddAudienceMembers(ctx context.Context, userId, listId string, request *model.AudienceMembersPost) error {
+	if _, err := s.ownedList(ctx, userId, listId); err != nil {
+		return err
+	}
+	memberIds := slices.Compact(slices.Sorted(slices.Values(request.UserIds)))
+	if slices.Contains(memberIds, userId) {
+		return errors.New("can not add yourself to an audience list")
+	}
+	existing, err := s.repo.CountExistingUsers(ctx, memberIds)
+	if err != nil {
+		return err
+	}
+	if existing != len(memberIds) {
+		return errors.New("user was not found")
+	}
+	members := make([]model.AudienceMember, 0, len(memberIds))
+	for _, memberId := range memberIds {
+		members = append(members, model.AudienceMember{ListId: listId, MemberId: memberId, CreatedAt: time.Now()})
+	}
+	if err := s.repo.AddAudienceMembers(ctx, members); err != nil {
+		return err
+	}
+	s.refreshAudience(ctx, userId, memberIds)
+	return nil
+}
+
+func (s *RelationshipsService) RemoveAudienceMembers(ctx context.Context, userId, listId string, request *model.AudienceMembersPost) error {
+	if _, err := s.ownedList(ctx, userId, listId); err != nil {
+		return err
+	}
+	if err := s.repo.RemoveAudienceMembers(ctx, listId, request.UserIds); err != nil {
+		return err
+	}
+	s.refreshAudience(ctx, userId, request.UserIds)
+	return nil
+}
+
+func (s *RelationshipsService) ownedList(ctx context.Context, userId, listId string) (*model.AudienceList, error) {
+	list, err := s.repo.GetAudienceList(ctx, listId)
+	if err != nil || list.OwnerId != userId {
+		return nil, errors.New("this list was not found")
+	}
+	return list, nil
+}
+
+// refreshAudience rebuilds the owner's posts in the timelines of members whose
+// access changed. Timelines are filtered again when read, so posts a member
+// lost access to are never shown even if eviction fails.
+func (s *RelationshipsService) refreshAudience(ctx context.Context, ownerId string, memberIds []string) {
+	for _, memberId := range memberIds {
+		following, err := s.repo.IsActiveFollow(ctx, memberId, ownerId)
+		if err != nil || !following {
+			continue
+		}
+		logTimelineError("eviction", s.timeline.Evict(ctx, memberId, ownerId))
+		logTimelineError("backfill", s.timeline.Backfill(ctx, memberId, ownerId))
+	}
+	logTimelineError("cache invalidation", s.timeline.InvalidateFeeds(ctx, memberIds...))
+}
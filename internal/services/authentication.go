@@ -16,6 +16,9 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrAccountSuspended is returned for valid tokens of a suspended account
+var ErrAccountSuspended = errors.New("account is suspended")
+
 type IJwtAuthService interface {
 	GenerateToken(ctx context.Context, userId string, isRefeshToken bool) (string, error)
 	ValidateToken(ctx context.Context, token string, isRefreshToken bool) (*jwt.StandardClaims, error)
@@ -117,6 +120,13 @@ func (s *JwtAuthService) ValidateToken(ctx context.Context, token string, isRefr
 	if claims.ExpiresAt < now {
 		return nil, errors.New("token expired")
 	}
+	suspended, err := s.Repo.IsSuspended(ctx, claims.Subject)
+	if err != nil {
+		return nil, errors.New("can not check account suspension")
+	}
+	if suspended {
+		return nil, ErrAccountSuspended
+	}
 
 	return claims, nil
 }
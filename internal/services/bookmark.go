@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"program/internal/model"
+	newsfeedRepo "program/internal/repositories/newfeed"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IBookmarkService interface {
+	AddBookmark(ctx context.Context, userId, postId string, bookmark *model.BookmarkPost) (any, error)
+	RemoveBookmark(ctx context.Context, userId, postId string) error
+	GetBookmarks(ctx context.Context, userId, collection string, limit int, cursor string) (any, error)
+	GetCollections(ctx context.Context, userId string) (any, error)
+}
+
+type BookmarkService struct {
+	repo     newsfeedRepo.INewsfeedRepo
+	newsfeed INewsfeedService
+}
+
+func NewBookmarkService(repo newsfeedRepo.INewsfeedRepo, newsfeed INewsfeedService) IBookmarkService {
+	return &BookmarkService{
+		repo:     repo,
+		newsfeed: newsfeed,
+	}
+}
+
+// AddBookmark saves a post the user can see into a collection, moving it
+// there if it was already saved elsewhere.
+func (s *BookmarkService) AddBookmark(ctx context.Context, userId, postId string, bookmark *model.BookmarkPost) (any, error) {
+	posts, err := s.repo.GetPostsByIds(ctx, userId, []string{postId})
+	if err != nil {
+		return nil, err
+	}
+	if len(*posts) == 0 {
+		return nil, errors.New("this post was not found")
+	}
+	collection := strings.TrimSpace(bookmark.Collection)
+	if collection == "" {
+		collection = model.DefaultCollection
+	}
+	saved := &model.Bookmark{
+		BookmarkId: uuid.NewString(),
+		UserId:     userId,
+		PostId:     postId,
+		Collection: collection,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.SaveBookmark(ctx, saved); err != nil {
+		return nil, err
+	}
+	return &map[string]any{
+		"post_id":    postId,
+		"collection": collection,
+	}, nil
+}
+
+func (s *BookmarkService) RemoveBookmark(ctx context.Context, userId, postId string) error {
+	return s.repo.DeleteBookmark(ctx, userId, postId)
+}
+
+// GetBookmarks lists the saved posts of a user, newest first. Posts that were
+// deleted or that the user can no longer see are left out. The cursor is
+// opaque to clients and empty on the last page.
+func (s *BookmarkService) GetBookmarks(ctx context.Context, userId, collection string, limit int, cursor string) (any, error) {
+	afterTime, afterId, err := decodeKeysetCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	bookmarks, err := s.repo.GetBookmarks(ctx, userId, collection, afterTime, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	postIds := make([]string, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		postIds = append(postIds, bookmark.PostId)
+	}
+	posts, err := s.newsfeed.GetPostsByIds(ctx, userId, postIds)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]model.NewsFeed, len(*posts))
+	for _, post := range *posts {
+		byId[post.PostId] = post
+	}
+
+	page := &model.BookmarkPage{Results: make([]model.BookmarkedPost, 0, len(bookmarks))}
+	for _, bookmark := range bookmarks {
+		if post, ok := byId[bookmark.PostId]; ok {
+			page.Results = append(page.Results, model.BookmarkedPost{
+				NewsFeed:   post,
+				Collection: bookmark.Collection,
+				SavedAt:    bookmark.CreatedAt,
+			})
+		}
+	}
+	if limit > 0 && len(bookmarks) == limit {
+		last := bookmarks[len(bookmarks)-1]
+		page.NextCursor = encodeKeysetCursor(last.CreatedAt, last.BookmarkId)
+	}
+	return page, nil
+}
+
+func (s *BookmarkService) GetCollections(ctx context.Context, userId string) (any, error) {
+	return s.repo.GetBookmarkCollections(ctx, userId)
+}
+
+// ErrInvalidCursor is returned for cursors that were not issued by this server
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// A keyset cursor is the position of the last item of a page, its creation
+// time and id. Bookmarks and the older pages of the newsfeed use it.
+func encodeKeysetCursor(createdAt time.Time, id string) string {
+	raw := strconv.FormatInt(createdAt.UnixNano(), 10) + ":" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeKeysetCursor(cursor string) (time.Time, string, error) {
+	if cursor == "" {
+		return time.Time{}, "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found || id == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return time.Unix(0, unixNano), id, nil
+}
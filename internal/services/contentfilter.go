@@ -0,0 +1,392 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"program/internal/model"
+	moderationRepo "program/internal/repositories/moderation"
+	newsfeedRepo "program/internal/repositories/newfeed"
+	"program/internal/textnorm"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// FilterVerdict is the outcome of a content filter, from the most lenient
+// to the strictest.
+type FilterVerdict int
+
+const (
+	FilterAllow FilterVerdict = iota
+	// FilterFlag lets the content through and sends it to the moderation queue
+	FilterFlag
+	FilterReject
+)
+
+// FilterInput is a post or a comment about to be stored. Edits are not
+// counted by the rate and repetition filters, new content is counted once
+// it is stored.
+type FilterInput struct {
+	UserId  string
+	Target  model.ReportTarget
+	Content string
+	Edit    bool
+}
+
+// FilterResult explains a verdict. Reason and Detail become the report of
+// flagged content, Detail is also the error of rejected content.
+type FilterResult struct {
+	Verdict FilterVerdict
+	Filter  string
+	Reason  model.ReportReason
+	Detail  string
+}
+
+type IContentFilter interface {
+	Name() string
+	Check(ctx context.Context, input *FilterInput) (*FilterResult, error)
+}
+
+// ICountingFilter is a filter judging content by what the user stored
+// before. Check only reads the counts, Record adds stored content to them so
+// that rejected or failed content is never counted.
+type ICountingFilter interface {
+	IContentFilter
+	Record(ctx context.Context, input *FilterInput) error
+}
+
+// IContentFilterPipeline runs the configured filters on new and edited
+// content and reports what they flagged.
+type IContentFilterPipeline interface {
+	Check(ctx context.Context, input *FilterInput) (*FilterResult, error)
+	Flag(ctx context.Context, targetId string, input *FilterInput, result *FilterResult)
+	Record(ctx context.Context, input *FilterInput)
+}
+
+type ContentFilterPipeline struct {
+	filters []IContentFilter
+	reports moderationRepo.IModerationRepo
+}
+
+// NewContentFilterPipeline chains filters in order, cheap and stateless
+// filters should come first.
+func NewContentFilterPipeline(reports moderationRepo.IModerationRepo, filters ...IContentFilter) IContentFilterPipeline {
+	return &ContentFilterPipeline{
+		filters: filters,
+		reports: reports,
+	}
+}
+
+// Check returns the strictest verdict of the chain, stopping at the first
+// rejection. A failing filter is logged and skipped rather than blocking
+// every post.
+func (p *ContentFilterPipeline) Check(ctx context.Context, input *FilterInput) (*FilterResult, error) {
+	verdict := &FilterResult{Verdict: FilterAllow}
+	for _, filter := range p.filters {
+		result, err := filter.Check(ctx, input)
+		if err != nil {
+			log.WithError(err).WithField("filter", filter.Name()).Warn("content filter failed")
+			continue
+		}
+		if result.Verdict <= verdict.Verdict {
+			continue
+		}
+		result.Filter = filter.Name()
+		verdict = result
+		if verdict.Verdict == FilterReject {
+			break
+		}
+	}
+	return verdict, nil
+}
+
+// Flag queues stored content for review, reported by the system moderator
+func (p *ContentFilterPipeline) Flag(ctx context.Context, targetId string, input *FilterInput, result *FilterResult) {
+	if result.Verdict != FilterFlag {
+		return
+	}
+	report := &model.Report{
+		ReportId:   uuid.NewString(),
+		ReporterId: model.SystemModerator,
+		TargetType: input.Target,
+		TargetId:   targetId,
+		Reason:     result.Reason,
+		Details:    truncateRunes(result.Filter+": "+result.Detail, 500),
+		Status:     model.OpenReport,
+		CreatedAt:  time.Now(),
+	}
+	if _, err := p.reports.CreateReport(ctx, report); err != nil {
+		log.WithError(err).WithField("targetId", targetId).Warn("can not queue flagged content")
+	}
+}
+
+// Record counts stored content in the counting filters. A failing filter is
+// logged, the content is already stored.
+func (p *ContentFilterPipeline) Record(ctx context.Context, input *FilterInput) {
+	for _, filter := range p.filters {
+		counting, ok := filter.(ICountingFilter)
+		if !ok {
+			continue
+		}
+		if err := counting.Record(ctx, input); err != nil {
+			log.WithError(err).WithField("filter", filter.Name()).Warn("content filter can not count content")
+		}
+	}
+}
+
+func allow() *FilterResult {
+	return &FilterResult{Verdict: FilterAllow}
+}
+
+// LengthLimit bounds the length of a content in runes, after trimming
+type LengthLimit struct {
+	Min int
+	Max int
+}
+
+// LengthFilter rejects blank and oversized content
+type LengthFilter struct {
+	Limits map[model.ReportTarget]LengthLimit
+}
+
+func (f *LengthFilter) Name() string {
+	return "length"
+}
+
+func (f *LengthFilter) Check(ctx context.Context, input *FilterInput) (*FilterResult, error) {
+	limit, ok := f.Limits[input.Target]
+	if !ok {
+		return allow(), nil
+	}
+	length := utf8.RuneCountInString(strings.TrimSpace(input.Content))
+	switch {
+	case length < max(limit.Min, 1):
+		return &FilterResult{Verdict: FilterReject, Reason: model.ReasonOther, Detail: fmt.Sprintf("%s can not be empty", input.Target)}, nil
+	case limit.Max > 0 && length > limit.Max:
+		return &FilterResult{Verdict: FilterReject, Reason: model.ReasonOther, Detail: fmt.Sprintf("%s is longer than %d characters", input.Target, limit.Max)}, nil
+	}
+	return allow(), nil
+}
+
+// BannedWordFilter matches whole words and phrases after folding case and
+// Vietnamese diacritics, so "Đồ Ngốc" matches "do ngoc". Stretched words
+// ("baaad") and digits used as letters ("b4d") are caught too. Phrases in
+// Rejected block the content, phrases in Flagged queue it for review.
+type BannedWordFilter struct {
+	rejected [][]string
+	flagged  [][]string
+}
+
+func NewBannedWordFilter(rejected, flagged []string) *BannedWordFilter {
+	return &BannedWordFilter{
+		rejected: tokenizePhrases(rejected),
+		flagged:  tokenizePhrases(flagged),
+	}
+}
+
+func tokenizePhrases(phrases []string) [][]string {
+	tokenized := make([][]string, 0, len(phrases))
+	for _, phrase := range phrases {
+		if tokens := textnorm.Tokenize(phrase); len(tokens) > 0 {
+			tokenized = append(tokenized, tokens)
+		}
+	}
+	return tokenized
+}
+
+func (f *BannedWordFilter) Name() string {
+	return "banned_words"
+}
+
+func (f *BannedWordFilter) Check(ctx context.Context, input *FilterInput) (*FilterResult, error) {
+	tokens := textnorm.Tokenize(input.Content)
+	if phrase := findPhrase(tokens, f.rejected); phrase != "" {
+		return &FilterResult{Verdict: FilterReject, Reason: model.ReasonHarassment, Detail: "content contains a banned word"}, nil
+	}
+	if phrase := findPhrase(tokens, f.flagged); phrase != "" {
+		return &FilterResult{Verdict: FilterFlag, Reason: model.ReasonHarassment, Detail: "content contains " + phrase}, nil
+	}
+	return allow(), nil
+}
+
+// findPhrase returns the first phrase found as consecutive tokens
+func findPhrase(tokens []string, phrases [][]string) string {
+	for _, phrase := range phrases {
+		for start := 0; start+len(phrase) <= len(tokens); start++ {
+			matched := true
+			for i, word := range phrase {
+				if !wordMatches(tokens[start+i], word) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				return strings.Join(phrase, " ")
+			}
+		}
+	}
+	return ""
+}
+
+var leetLetters = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b")
+
+func wordMatches(token, word string) bool {
+	if token == word || collapseRepeats(token) == word {
+		return true
+	}
+	// Plain numbers are left alone, only words mixing digits and letters
+	// are read as leetspeak
+	if strings.IndexFunc(token, unicode.IsDigit) < 0 || strings.IndexFunc(token, unicode.IsLetter) < 0 {
+		return false
+	}
+	token = leetLetters.Replace(token)
+	return token == word || collapseRepeats(token) == word
+}
+
+// collapseRepeats squeezes runs of a letter down to one, "baaad" -> "bad"
+func collapseRepeats(word string) string {
+	var b strings.Builder
+	var last rune
+	for i, r := range word {
+		if i > 0 && r == last {
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return b.String()
+}
+
+// LinkBlacklistFilter rejects links to blocked domains and their subdomains
+type LinkBlacklistFilter struct {
+	Domains []string
+}
+
+func (f *LinkBlacklistFilter) Name() string {
+	return "link_blacklist"
+}
+
+func (f *LinkBlacklistFilter) Check(ctx context.Context, input *FilterInput) (*FilterResult, error) {
+	for _, link := range linkPattern.FindAllString(input.Content, -1) {
+		target, err := url.Parse(strings.TrimRight(link, ".,;:!?)]}"))
+		if err != nil {
+			continue
+		}
+		host := strings.TrimSuffix(strings.ToLower(target.Hostname()), ".")
+		for _, domain := range f.Domains {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return &FilterResult{Verdict: FilterReject, Reason: model.ReasonSpam, Detail: "content links to a blocked website"}, nil
+			}
+		}
+	}
+	return allow(), nil
+}
+
+// RepeatedContentFilter catches a user posting the same text again and
+// again: the repeats up to MaxRepeats are flagged, the next are rejected.
+type RepeatedContentFilter struct {
+	Counters   newsfeedRepo.INewsfeedRepo
+	Window     time.Duration
+	MaxRepeats int
+}
+
+func (f *RepeatedContentFilter) Name() string {
+	return "repeated_content"
+}
+
+// key names the counter of a content, or is empty when it is not counted.
+// Contents are compared on the folded words, so case, accents and spacing
+// do not matter.
+func (f *RepeatedContentFilter) key(input *FilterInput) string {
+	if input.Edit || f.MaxRepeats <= 0 {
+		return ""
+	}
+	sum := sha1.Sum([]byte(strings.Join(textnorm.Tokenize(input.Content), " ")))
+	return "repeat:" + input.UserId + ":" + hex.EncodeToString(sum[:])
+}
+
+func (f *RepeatedContentFilter) Check(ctx context.Context, input *FilterInput) (*FilterResult, error) {
+	key := f.key(input)
+	if key == "" {
+		return allow(), nil
+	}
+	stored, err := f.Counters.GetFilterCounter(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	// The content is judged as if it were stored
+	count := stored + 1
+	switch {
+	case count > int64(f.MaxRepeats):
+		return &FilterResult{Verdict: FilterReject, Reason: model.ReasonSpam, Detail: "you already posted this several times"}, nil
+	case count > 1:
+		return &FilterResult{Verdict: FilterFlag, Reason: model.ReasonSpam, Detail: fmt.Sprintf("same content posted %d times", count)}, nil
+	}
+	return allow(), nil
+}
+
+func (f *RepeatedContentFilter) Record(ctx context.Context, input *FilterInput) error {
+	key := f.key(input)
+	if key == "" {
+		return nil
+	}
+	_, err := f.Counters.IncrementFilterCounter(ctx, key, f.Window)
+	return err
+}
+
+// RateLimit allows Count contents per Window
+type RateLimit struct {
+	Count  int
+	Window time.Duration
+}
+
+// RateFilter rejects users posting faster than the limit of the target
+type RateFilter struct {
+	Counters newsfeedRepo.INewsfeedRepo
+	Limits   map[model.ReportTarget]RateLimit
+}
+
+func (f *RateFilter) Name() string {
+	return "rate"
+}
+
+// limit returns the limit of a content and the name of its counter, ok is
+// false when it is not counted.
+func (f *RateFilter) limit(input *FilterInput) (limit RateLimit, key string, ok bool) {
+	limit, ok = f.Limits[input.Target]
+	if input.Edit || !ok || limit.Count <= 0 {
+		return limit, "", false
+	}
+	return limit, "rate:" + string(input.Target) + ":" + input.UserId, true
+}
+
+func (f *RateFilter) Check(ctx context.Context, input *FilterInput) (*FilterResult, error) {
+	limit, key, ok := f.limit(input)
+	if !ok {
+		return allow(), nil
+	}
+	stored, err := f.Counters.GetFilterCounter(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if stored >= int64(limit.Count) {
+		return &FilterResult{Verdict: FilterReject, Reason: model.ReasonSpam, Detail: fmt.Sprintf("you are posting %ss too fast, try again later", input.Target)}, nil
+	}
+	return allow(), nil
+}
+
+func (f *RateFilter) Record(ctx context.Context, input *FilterInput) error {
+	limit, key, ok := f.limit(input)
+	if !ok {
+		return nil
+	}
+	_, err := f.Counters.IncrementFilterCounter(ctx, key, limit.Window)
+	return err
+}
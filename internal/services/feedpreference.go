@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"program/internal/model"
+	newsfeedRepo "program/internal/repositories/newfeed"
+	relationshipsRepo "program/internal/repositories/relationships"
+	timelineRepo "program/internal/repositories/timeline"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// snoozeDuration is how long a snoozed author stays out of the newsfeed
+const snoozeDuration = 30 * 24 * time.Hour
+
+// Number of expired snoozes ended per scheduler run
+const snoozeBatchSize = 100
+
+// IFeedPreferenceService manages what a viewer keeps out of their newsfeed.
+// Preferences only affect GetNewsfeed, profiles, search and direct links
+// still show everything the viewer can see.
+type IFeedPreferenceService interface {
+	HidePost(ctx context.Context, userId, postId string, hide *model.HidePost) error
+	UnhidePost(ctx context.Context, userId, postId string) error
+	SnoozeAuthor(ctx context.Context, userId, authorId string) (any, error)
+	UnsnoozeAuthor(ctx context.Context, userId, authorId string) error
+	GetFeedPreferences(ctx context.Context, userId string, limit, offset int) (any, error)
+	EndExpiredSnoozes(ctx context.Context) (int, error)
+}
+
+type FeedPreferenceService struct {
+	repo      newsfeedRepo.INewsfeedRepo
+	follows   relationshipsRepo.IRelationshipsRepo
+	timeline  ITimelineService
+	timelines timelineRepo.ITimelineRepo
+}
+
+func NewFeedPreferenceService(repo newsfeedRepo.INewsfeedRepo, follows relationshipsRepo.IRelationshipsRepo, timeline ITimelineService, timelines timelineRepo.ITimelineRepo) IFeedPreferenceService {
+	return &FeedPreferenceService{
+		repo:      repo,
+		follows:   follows,
+		timeline:  timeline,
+		timelines: timelines,
+	}
+}
+
+// HidePost takes a post the viewer can see out of their newsfeed
+func (s *FeedPreferenceService) HidePost(ctx context.Context, userId, postId string, hide *model.HidePost) error {
+	posts, err := s.repo.GetPostsByIds(ctx, userId, []string{postId})
+	if err != nil {
+		return err
+	}
+	if len(*posts) == 0 {
+		return errors.New("this post was not found")
+	}
+	if (*posts)[0].UserId == userId {
+		return errors.New("can not hide your own post")
+	}
+	reason := hide.Reason
+	if reason == "" {
+		reason = model.HiddenReason
+	}
+	err = s.repo.HidePost(ctx, &model.HiddenPost{
+		UserId:    userId,
+		PostId:    postId,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	logTimelineError("eviction", s.timelines.RemoveFromTimeline(ctx, userId, []string{postId}))
+	logTimelineError("cache invalidation", s.timeline.InvalidateFeeds(ctx, userId))
+	return nil
+}
+
+// UnhidePost puts a hidden post back. It returns to the stored timeline with
+// the other recent posts of its author when the viewer follows them.
+func (s *FeedPreferenceService) UnhidePost(ctx context.Context, userId, postId string) error {
+	if err := s.repo.UnhidePost(ctx, userId, postId); err != nil {
+		return err
+	}
+	if post, err := s.repo.GetPost(ctx, postId); err == nil {
+		s.restoreAuthor(ctx, userId, post.UserId)
+	}
+	logTimelineError("cache invalidation", s.timeline.InvalidateFeeds(ctx, userId))
+	return nil
+}
+
+// SnoozeAuthor keeps an author out of the newsfeed for snoozeDuration, after
+// which EndExpiredSnoozes brings them back. Snoozing again restarts the period.
+func (s *FeedPreferenceService) SnoozeAuthor(ctx context.Context, userId, authorId string) (any, error) {
+	if authorId == userId {
+		return nil, errors.New("can not snooze yourself")
+	}
+	existing, err := s.follows.CountExistingUsers(ctx, []string{authorId})
+	if err != nil {
+		return nil, err
+	}
+	if existing == 0 {
+		return nil, errors.New("user was not found")
+	}
+	now := time.Now()
+	snooze := &model.SnoozedAuthor{
+		UserId:    userId,
+		AuthorId:  authorId,
+		Until:     now.Add(snoozeDuration),
+		CreatedAt: now,
+	}
+	if err := s.repo.SnoozeAuthor(ctx, snooze); err != nil {
+		return nil, err
+	}
+	logTimelineError("eviction", s.timeline.Evict(ctx, userId, authorId))
+	logTimelineError("cache invalidation", s.timeline.InvalidateFeeds(ctx, userId))
+	return &map[string]any{
+		"author_id": authorId,
+		"until":     snooze.Until,
+	}, nil
+}
+
+// UnsnoozeAuthor ends a snooze early. Posts published during the snooze come
+// back as long as they are recent enough for the timeline.
+func (s *FeedPreferenceService) UnsnoozeAuthor(ctx context.Context, userId, authorId string) error {
+	if err := s.repo.UnsnoozeAuthor(ctx, userId, authorId); err != nil {
+		return err
+	}
+	s.restoreAuthor(ctx, userId, authorId)
+	logTimelineError("cache invalidation", s.timeline.InvalidateFeeds(ctx, userId))
+	return nil
+}
+
+// EndExpiredSnoozes brings back the authors whose snooze ran out like an
+// early unsnooze does, and forgets the snoozes. It returns how many ended.
+func (s *FeedPreferenceService) EndExpiredSnoozes(ctx context.Context) (int, error) {
+	now := time.Now()
+	expired, err := s.repo.GetExpiredSnoozes(ctx, now, snoozeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	ended := 0
+	for _, snooze := range expired {
+		deleted, err := s.repo.DeleteExpiredSnooze(ctx, snooze.UserId, snooze.AuthorId, now)
+		if err != nil {
+			log.WithError(err).WithField("userId", snooze.UserId).Warn("can not end expired snooze")
+			continue
+		}
+		if !deleted {
+			continue
+		}
+		s.restoreAuthor(ctx, snooze.UserId, snooze.AuthorId)
+		logTimelineError("cache invalidation", s.timeline.InvalidateFeeds(ctx, snooze.UserId))
+		ended++
+	}
+	return ended, nil
+}
+
+func (s *FeedPreferenceService) restoreAuthor(ctx context.Context, userId, authorId string) {
+	following, err := s.follows.IsActiveFollow(ctx, userId, authorId)
+	if err != nil || !following {
+		return
+	}
+	logTimelineError("backfill", s.timeline.Backfill(ctx, userId, authorId))
+}
+
+// GetFeedPreferences lists the hidden posts, newest first, and the running
+// snoozes of a user.
+func (s *FeedPreferenceService) GetFeedPreferences(ctx context.Context, userId string, limit, offset int) (any, error) {
+	hidden, err := s.repo.GetHiddenPosts(ctx, userId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	snoozed, err := s.repo.GetSnoozedAuthors(ctx, userId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &model.FeedPreferences{
+		HiddenPosts:    hidden,
+		SnoozedAuthors: snoozed,
+	}, nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// A hashtag starts after a non-word character and runs over letters, digits
+// and underscores, so Vietnamese tags such as #phởHàNội are kept whole.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])#([\p{L}\p{N}_]{1,100})`)
+
+// NormalizeHashtag gives the form a tag is indexed and looked up by
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(norm.NFC.String(strings.TrimPrefix(tag, "#")))
+}
+
+// ExtractHashtags returns the distinct normalized hashtags of a post content,
+// in order of first appearance. Tags made only of digits are ignored.
+func ExtractHashtags(content string) []string {
+	tags := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range hashtagPattern.FindAllStringSubmatch(norm.NFC.String(content), -1) {
+		tag := NormalizeHashtag(match[1])
+		if seen[tag] || strings.Trim(tag, "0123456789") == "" {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"math"
+	"program/internal/model"
+	newsfeedRepo "program/internal/repositories/newfeed"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// IInsightsService records what viewers see in their feeds and reports it to
+// the authors.
+type IInsightsService interface {
+	RecordImpressions(ctx context.Context, viewerId string, batch *model.ImpressionBatch) (any, error)
+	GetPostInsights(ctx context.Context, userId, postId string, days int) (any, error)
+	GetInsights(ctx context.Context, userId string, limit, offset int) (any, error)
+}
+
+type InsightsService struct {
+	repo newsfeedRepo.INewsfeedRepo
+}
+
+func NewInsightsService(repo newsfeedRepo.INewsfeedRepo) IInsightsService {
+	return &InsightsService{
+		repo: repo,
+	}
+}
+
+// maxDwell bounds the dwell time of one impression, a post left on screen
+// does not keep counting
+const maxDwell = time.Hour
+
+// RecordImpressions adds a batch to the rollups of the current UTC day. A
+// viewer makes at most one impression of a post per day, repeated and resent
+// entries are dropped. Posts the viewer can not see and their own posts are
+// ignored.
+func (s *InsightsService) RecordImpressions(ctx context.Context, viewerId string, batch *model.ImpressionBatch) (any, error) {
+	postIds := make([]string, 0, len(batch.Impressions))
+	dwell := make(map[string]int64)
+	for _, impression := range batch.Impressions {
+		if _, ok := dwell[impression.PostId]; !ok {
+			postIds = append(postIds, impression.PostId)
+		}
+		dwell[impression.PostId] += impression.DwellMs
+	}
+
+	targets, err := s.repo.GetImpressionTargets(ctx, viewerId, postIds)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return map[string]int{"recorded": 0}, nil
+	}
+	targetIds := make([]string, 0, len(targets))
+	for _, target := range targets {
+		targetIds = append(targetIds, target.PostId)
+	}
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	firstSeen, err := s.repo.MarkImpressionsSeen(ctx, viewerId, day, targetIds)
+	if err != nil {
+		return nil, err
+	}
+
+	rollups := make([]model.PostDailyStats, 0, len(firstSeen))
+	seenIds := make([]string, 0, len(firstSeen))
+	reached := make(map[string]bool, len(firstSeen))
+	for _, target := range targets {
+		if !firstSeen[target.PostId] {
+			continue
+		}
+		stats := model.PostDailyStats{
+			PostId:      target.PostId,
+			Day:         day,
+			Impressions: 1,
+			DwellMs:     min(dwell[target.PostId], maxDwell.Milliseconds()),
+		}
+		if target.Follower {
+			stats.FollowerImpressions = 1
+		} else {
+			stats.NonFollowerImpressions = 1
+		}
+		rollups = append(rollups, stats)
+		seenIds = append(seenIds, target.PostId)
+		reached[target.PostId] = target.Follower
+	}
+	if err := s.repo.AddPostDailyStats(ctx, rollups); err != nil {
+		// The posts were marked as seen today, they must be counted when the
+		// client retries the batch
+		if err := s.repo.UnmarkImpressionsSeen(ctx, viewerId, day, seenIds); err != nil {
+			log.WithError(err).WithField("viewerId", viewerId).Warn("can not forget unrecorded impressions")
+		}
+		return nil, err
+	}
+	if err := s.repo.AddPostReach(ctx, viewerId, reached); err != nil {
+		log.WithError(err).WithField("viewerId", viewerId).Warn("can not count post reach")
+	}
+	return map[string]int{"recorded": len(rollups)}, nil
+}
+
+// attachReach sets the lifetime reach of the posts
+func (s *InsightsService) attachReach(ctx context.Context, insights []model.PostInsights) error {
+	postIds := make([]string, 0, len(insights))
+	for _, post := range insights {
+		postIds = append(postIds, post.PostId)
+	}
+	reach, err := s.repo.GetPostReach(ctx, postIds)
+	if err != nil {
+		return err
+	}
+	for i := range insights {
+		post := reach[insights[i].PostId]
+		insights[i].Reach = post.Reach
+		insights[i].FollowerReach = post.FollowerReach
+		insights[i].NonFollowerReach = post.NonFollowerReach
+	}
+	return nil
+}
+
+// GetPostInsights returns the lifetime insights of one of the user's posts
+// with the rollups of its last days.
+func (s *InsightsService) GetPostInsights(ctx context.Context, userId, postId string, days int) (any, error) {
+	insights, err := s.repo.GetPostInsights(ctx, userId, postId)
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+	insights.Daily, err = s.repo.GetPostDailyStats(ctx, postId, since)
+	if err != nil {
+		return nil, err
+	}
+	posts := []model.PostInsights{*insights}
+	if err := s.attachReach(ctx, posts); err != nil {
+		return nil, err
+	}
+	summarizeInsights(&posts[0])
+	return &posts[0], nil
+}
+
+// GetInsights lists the insights of the user's posts, newest first
+func (s *InsightsService) GetInsights(ctx context.Context, userId string, limit, offset int) (any, error) {
+	insights, err := s.repo.GetAuthorInsights(ctx, userId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachReach(ctx, insights); err != nil {
+		return nil, err
+	}
+	for i := range insights {
+		summarizeInsights(&insights[i])
+	}
+	return insights, nil
+}
+
+// summarizeInsights derives the rates from the summed counters
+func summarizeInsights(insights *model.PostInsights) {
+	if insights.Reach > 0 {
+		rate := float64(insights.Engagements) / float64(insights.Reach)
+		insights.EngagementRate = math.Round(rate*10000) / 10000
+	}
+	if insights.Impressions > 0 {
+		insights.AvgDwellMs = insights.DwellMs / insights.Impressions
+	}
+}
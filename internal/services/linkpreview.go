@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"program/internal/model"
+	newsfeedRepo "program/internal/repositories/newfeed"
+	timelineRepo "program/internal/repositories/timeline"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type ILinkPreviewWorker interface {
+	Enqueue(postId, content string)
+	Run(ctx context.Context)
+}
+
+// LinkPreviewConfig sizes the background unfurling. Jobs are dropped when the
+// queue is full, the post then simply has no preview.
+type LinkPreviewConfig struct {
+	Workers   int
+	QueueSize int
+	CacheTTL  time.Duration
+	// FailureTTL is how long a link that could not be fetched is not tried
+	// again, it is not cached at all when zero
+	FailureTTL time.Duration
+}
+
+type linkPreviewJob struct {
+	postId string
+	link   string
+}
+
+type LinkPreviewWorker struct {
+	repo      newsfeedRepo.INewsfeedRepo
+	feedCache timelineRepo.ITimelineRepo
+	unfurler  IUnfurler
+	config    LinkPreviewConfig
+	jobs      chan linkPreviewJob
+}
+
+func NewLinkPreviewWorker(repo newsfeedRepo.INewsfeedRepo, feedCache timelineRepo.ITimelineRepo, unfurler IUnfurler, config LinkPreviewConfig) ILinkPreviewWorker {
+	return &LinkPreviewWorker{
+		repo:      repo,
+		feedCache: feedCache,
+		unfurler:  unfurler,
+		config:    config,
+		jobs:      make(chan linkPreviewJob, config.QueueSize),
+	}
+}
+
+// Enqueue schedules the preview of the first link of a post content. A
+// content without link clears the preview of the post.
+func (w *LinkPreviewWorker) Enqueue(postId, content string) {
+	select {
+	case w.jobs <- linkPreviewJob{postId: postId, link: ExtractLink(content)}:
+	default:
+		log.WithField("postId", postId).Warn("link preview queue is full")
+	}
+}
+
+// Run processes the queue with the configured number of workers until ctx is
+// cancelled.
+func (w *LinkPreviewWorker) Run(ctx context.Context) {
+	done := make(chan struct{})
+	for i := 0; i < max(w.config.Workers, 1); i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-w.jobs:
+					if err := w.process(ctx, job); err != nil {
+						log.WithError(err).WithField("postId", job.postId).Warn("link preview failed")
+					}
+				}
+			}
+		}()
+	}
+	for i := 0; i < max(w.config.Workers, 1); i++ {
+		<-done
+	}
+}
+
+func (w *LinkPreviewWorker) process(ctx context.Context, job linkPreviewJob) error {
+	// The post may have been edited since the job was queued
+	post, err := w.repo.GetPost(ctx, job.postId)
+	if err != nil {
+		return nil
+	}
+	if ExtractLink(post.Content) != job.link {
+		return nil
+	}
+	if job.link == "" {
+		if err := w.repo.DeleteLinkPreview(ctx, job.postId); err != nil {
+			return err
+		}
+		return w.feedCache.DeleteCachedPost(ctx, job.postId)
+	}
+
+	preview, cached, err := w.repo.GetCachedLinkPreview(ctx, job.link)
+	if err != nil {
+		log.WithError(err).Warn("link preview cache read failed")
+	}
+	if !cached {
+		ttl := w.config.CacheTTL
+		preview, err = w.unfurler.Unfurl(ctx, job.link)
+		if err != nil {
+			// The site may only be down for a moment
+			log.WithError(err).WithField("url", job.link).Info("link can not be unfurled")
+			preview = &model.LinkPreview{Url: job.link}
+			ttl = w.config.FailureTTL
+		}
+		if ttl > 0 {
+			if err := w.repo.CacheLinkPreview(ctx, job.link, preview, ttl); err != nil {
+				log.WithError(err).Warn("link preview cache write failed")
+			}
+		}
+	}
+	if preview.Empty() {
+		if err := w.repo.DeleteLinkPreview(ctx, job.postId); err != nil {
+			return err
+		}
+	} else {
+		preview.PostId = job.postId
+		if err := w.repo.SaveLinkPreview(ctx, preview); err != nil {
+			return err
+		}
+	}
+	return w.feedCache.DeleteCachedPost(ctx, job.postId)
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"program/internal/model"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A mention is an @ not preceded by a word character, so e-mail addresses
+// are not picked up.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.])(@[A-Za-z0-9_.]{1,50})`)
+
+// ExtractMentions returns the unresolved mentions of content, with offsets in
+// code points. Trailing dots are treated as punctuation.
+func ExtractMentions(content string) []model.Mention {
+	mentions := make([]model.Mention, 0)
+	for _, loc := range mentionPattern.FindAllStringSubmatchIndex(content, -1) {
+		// A username cut short by a letter it can not hold is not a mention
+		if next, _ := utf8.DecodeRuneInString(content[loc[3]:]); unicode.IsLetter(next) || unicode.IsDigit(next) {
+			continue
+		}
+		raw := strings.TrimRight(content[loc[2]:loc[3]], ".")
+		if len(raw) < 2 {
+			continue
+		}
+		mentions = append(mentions, model.Mention{
+			Username: raw[1:],
+			Offset:   utf8.RuneCountInString(content[:loc[2]]),
+			Length:   utf8.RuneCountInString(raw),
+		})
+	}
+	return mentions
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"program/internal/model"
+	migrationRepo "program/internal/repositories/migration"
+)
+
+type IMigrateService interface {
+	Migrate(ctx context.Context) ([]model.Migration, error)
+}
+
+// MigrateService brings the schema up to date with the code
+type MigrateService struct {
+	repo migrationRepo.IMigrationRepo
+}
+
+func NewMigrateService(repo migrationRepo.IMigrationRepo) IMigrateService {
+	return &MigrateService{
+		repo: repo,
+	}
+}
+
+// Migrate applies the migrations not applied yet, in order, and returns them.
+// It stops at the first failure, the ones before it stay applied.
+func (s *MigrateService) Migrate(ctx context.Context) ([]model.Migration, error) {
+	applied, err := s.repo.GetAppliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	done := make([]model.Migration, 0)
+	for _, migration := range s.repo.GetMigrations() {
+		if applied[migration.Version] {
+			continue
+		}
+		if err := s.repo.Apply(ctx, migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
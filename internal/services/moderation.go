@@ -0,0 +1,419 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"program/internal/model"
+	authenticationRepo "program/internal/repositories/auth"
+	moderationRepo "program/internal/repositories/moderation"
+	newsfeedRepo "program/internal/repositories/newfeed"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
+)
+
+// ErrNotModerator is returned when a regular user calls a moderation method
+var ErrNotModerator = errors.New("you don't have permission to moderate content")
+
+type IModerationService interface {
+	Report(ctx context.Context, userId string, report *model.ReportPost) (any, error)
+	GetQueue(ctx context.Context, userId string, status model.ReportStatus, limit, offset int) (any, error)
+	GetTargetReports(ctx context.Context, userId string, targetType model.ReportTarget, targetId string, limit, offset int) (any, error)
+	Decide(ctx context.Context, userId string, targetType model.ReportTarget, targetId string, decision *model.ModerationDecision) (any, error)
+	GetActions(ctx context.Context, userId string, filter model.ModerationActionFilter, limit, offset int) (any, error)
+}
+
+// ModerationConfig sets when reported content is hidden before a moderator
+// reviewed it. A threshold of 0 turns auto-hiding off.
+type ModerationConfig struct {
+	AutoHideThreshold int
+}
+
+type ModerationService struct {
+	repo     moderationRepo.IModerationRepo
+	posts    newsfeedRepo.INewsfeedRepo
+	newsfeed INewsfeedService
+	auth     authenticationRepo.IAuthenticationRepo
+	notifier INotificationService
+	config   ModerationConfig
+}
+
+func NewModerationService(repo moderationRepo.IModerationRepo, posts newsfeedRepo.INewsfeedRepo, newsfeed INewsfeedService, auth authenticationRepo.IAuthenticationRepo, notifier INotificationService, config ModerationConfig) IModerationService {
+	return &ModerationService{
+		repo:     repo,
+		posts:    posts,
+		newsfeed: newsfeed,
+		auth:     auth,
+		notifier: notifier,
+		config:   config,
+	}
+}
+
+// moderationTarget is the reported content as seen by moderation. SubjectId
+// is the user responsible for it, Hidden tells whether it is taken down.
+type moderationTarget struct {
+	Type      model.ReportTarget
+	Id        string
+	SubjectId string
+	PostId    *string
+	CommentId *string
+	Hidden    bool
+}
+
+// loadTarget finds a reported target. Content deleted by its author is not
+// found, there is nothing left to moderate.
+func (s *ModerationService) loadTarget(ctx context.Context, targetType model.ReportTarget, targetId string) (*moderationTarget, error) {
+	target := &moderationTarget{Type: targetType, Id: targetId}
+	switch targetType {
+	case model.ReportedPost:
+		post, err := s.posts.GetModeratedPost(ctx, targetId)
+		if err != nil || post.Status != model.PublishedPost {
+			return nil, errors.New("this post was not found")
+		}
+		target.SubjectId = post.UserId
+		target.PostId = &post.PostId
+		target.Hidden = post.Deleted == model.HiddenByModeration
+	case model.ReportedComment:
+		comment, err := s.posts.GetComment(ctx, targetId)
+		if err != nil || comment.Status == model.DeletedComment {
+			return nil, errors.New("this comment was not found")
+		}
+		target.SubjectId = comment.UserId
+		target.PostId = &comment.PostId
+		target.CommentId = &comment.CommentId
+		target.Hidden = comment.Status == model.HiddenComment
+	case model.ReportedProfile:
+		existed, err := s.repo.IsUserExisted(ctx, targetId)
+		if err != nil {
+			return nil, err
+		}
+		if !existed {
+			return nil, errors.New("user was not found")
+		}
+		target.SubjectId = targetId
+	default:
+		return nil, errors.New("unknown report target")
+	}
+	return target, nil
+}
+
+// Report files a report on content the user can see. Reporting the same
+// target twice is a no-op. Once AutoHideThreshold users have open reports on
+// a post or a comment, it is hidden until a moderator reviews it.
+func (s *ModerationService) Report(ctx context.Context, userId string, request *model.ReportPost) (any, error) {
+	target, err := s.loadTarget(ctx, request.TargetType, request.TargetId)
+	if err != nil {
+		return nil, err
+	}
+	if target.SubjectId == userId {
+		return nil, errors.New("can not report yourself")
+	}
+	if target.PostId != nil {
+		if err := s.posts.CheckFriendPrivacyPermission(ctx, userId, *target.PostId); err != nil {
+			return nil, errors.New("this post was not found")
+		}
+	}
+	report := &model.Report{
+		ReportId:   uuid.NewString(),
+		ReporterId: userId,
+		TargetType: request.TargetType,
+		TargetId:   request.TargetId,
+		Reason:     request.Reason,
+		Details:    strings.TrimSpace(request.Details),
+		Status:     model.OpenReport,
+		CreatedAt:  time.Now(),
+	}
+	created, err := s.repo.CreateReport(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+	if created && !target.Hidden {
+		s.autoHide(ctx, target)
+	}
+	return &map[string]any{
+		"status":  "successful",
+		"message": "thanks, the report will be reviewed",
+	}, nil
+}
+
+func (s *ModerationService) autoHide(ctx context.Context, target *moderationTarget) {
+	if s.config.AutoHideThreshold <= 0 || target.Type == model.ReportedProfile {
+		return
+	}
+	reporters, err := s.repo.CountOpenReporters(ctx, target.Type, target.Id)
+	if err != nil || reporters < s.config.AutoHideThreshold {
+		return
+	}
+	action := &model.ModerationAction{
+		ActionId:    uuid.NewString(),
+		ModeratorId: model.SystemModerator,
+		Action:      model.AutoHideAction,
+		TargetType:  target.Type,
+		TargetId:    target.Id,
+		SubjectId:   target.SubjectId,
+		CreatedAt:   time.Now(),
+	}
+	audit := func(tx *bun.Tx) error {
+		return s.repo.CreateActionTransaction(ctx, tx, action)
+	}
+	if err := s.setHidden(ctx, target, true, audit); err != nil {
+		log.WithError(err).WithField("targetId", target.Id).Warn("can not auto hide reported content")
+	}
+}
+
+// setHidden takes the target down or restores it, audit writes the decision
+// in the same transaction.
+func (s *ModerationService) setHidden(ctx context.Context, target *moderationTarget, hidden bool, audit func(tx *bun.Tx) error) error {
+	switch target.Type {
+	case model.ReportedPost:
+		return s.newsfeed.ModeratePost(ctx, target.Id, hidden, audit)
+	case model.ReportedComment:
+		return s.newsfeed.ModerateComment(ctx, target.Id, hidden, audit)
+	}
+	return errors.New("profiles can not be hidden, suspend the user instead")
+}
+
+func (s *ModerationService) requireModerator(ctx context.Context, userId string) error {
+	role, err := s.posts.GetRole(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if role != model.RoleModerator {
+		return ErrNotModerator
+	}
+	return nil
+}
+
+// GetQueue lists the reported targets with the given report status
+func (s *ModerationService) GetQueue(ctx context.Context, userId string, status model.ReportStatus, limit, offset int) (any, error) {
+	if err := s.requireModerator(ctx, userId); err != nil {
+		return nil, err
+	}
+	queue, err := s.repo.GetModerationQueue(ctx, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range queue {
+		for _, reason := range strings.Split(queue[i].ReasonList, ",") {
+			queue[i].Reasons = append(queue[i].Reasons, model.ReportReason(reason))
+		}
+	}
+	return &map[string]any{
+		"data":   queue,
+		"limit":  limit,
+		"offset": offset,
+	}, nil
+}
+
+// GetTargetReports returns the reports of one target with the decisions
+// already taken on it.
+func (s *ModerationService) GetTargetReports(ctx context.Context, userId string, targetType model.ReportTarget, targetId string, limit, offset int) (any, error) {
+	if err := s.requireModerator(ctx, userId); err != nil {
+		return nil, err
+	}
+	reports, err := s.repo.GetReports(ctx, targetType, targetId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	actions, err := s.repo.GetActions(ctx, model.ModerationActionFilter{TargetType: targetType, TargetId: targetId}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &map[string]any{
+		"reports": reports,
+		"actions": actions,
+		"limit":   limit,
+		"offset":  offset,
+	}, nil
+}
+
+// Decide applies a moderator's decision to a target and closes its open
+// reports: dismiss and restore dismiss them, other actions resolve them.
+// Every decision is written to the audit log in the transaction that applies
+// it, the warning and the suspension cache follow once it is committed.
+func (s *ModerationService) Decide(ctx context.Context, userId string, targetType model.ReportTarget, targetId string, decision *model.ModerationDecision) (any, error) {
+	if err := s.requireModerator(ctx, userId); err != nil {
+		return nil, err
+	}
+	target, err := s.loadTarget(ctx, targetType, targetId)
+	if err != nil {
+		return nil, err
+	}
+	if target.SubjectId == userId {
+		return nil, errors.New("can not moderate your own content")
+	}
+	action := &model.ModerationAction{
+		ActionId:    uuid.NewString(),
+		ModeratorId: userId,
+		Action:      decision.Action,
+		TargetType:  targetType,
+		TargetId:    targetId,
+		SubjectId:   target.SubjectId,
+		Note:        strings.TrimSpace(decision.Note),
+		CreatedAt:   time.Now(),
+	}
+	status := model.ResolvedReport
+	changed := false
+	switch decision.Action {
+	case model.DismissAction:
+		status = model.DismissedReport
+	case model.HideAction, model.RestoreAction:
+		if decision.Action == model.RestoreAction {
+			status = model.DismissedReport
+		}
+		// Content hidden automatically only needs its reports closed
+		changed = (decision.Action == model.HideAction) != target.Hidden
+	case model.WarnAction:
+	case model.SuspendAction:
+		if decision.Days <= 0 {
+			return nil, errors.New("days is required to suspend a user")
+		}
+		expiresAt := action.CreatedAt.AddDate(0, 0, decision.Days)
+		action.ExpiresAt = &expiresAt
+	case model.UnsuspendAction:
+	default:
+		return nil, errors.New("unknown moderation action")
+	}
+
+	// The audit row, the suspension and the closed reports are written in
+	// the transaction that hides or restores the target
+	record := func(tx *bun.Tx) error {
+		if err := s.repo.CreateActionTransaction(ctx, tx, action); err != nil {
+			return err
+		}
+		switch decision.Action {
+		case model.SuspendAction:
+			suspension := &model.Suspension{
+				UserId:    target.SubjectId,
+				ActionId:  action.ActionId,
+				Until:     *action.ExpiresAt,
+				CreatedAt: action.CreatedAt,
+			}
+			if err := s.repo.SuspendUserTransaction(ctx, tx, suspension); err != nil {
+				return err
+			}
+		case model.UnsuspendAction:
+			return s.repo.LiftSuspensionTransaction(ctx, tx, target.SubjectId)
+		}
+		return s.repo.CloseReportsTransaction(ctx, tx, targetType, targetId, userId, status)
+	}
+	if changed {
+		err = s.setHidden(ctx, target, decision.Action == model.HideAction, record)
+	} else {
+		err = s.recordDecision(ctx, record)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch decision.Action {
+	case model.WarnAction:
+		s.notifier.Notify(ctx, model.Notification{
+			UserId:    target.SubjectId,
+			Type:      model.ModerationWarning,
+			PostId:    target.PostId,
+			CommentId: target.CommentId,
+		})
+	case model.SuspendAction:
+		if err := s.auth.SuspendUser(ctx, target.SubjectId, time.Until(*action.ExpiresAt)); err != nil {
+			log.WithError(err).WithField("userId", target.SubjectId).Warn("can not cache suspension")
+		}
+	case model.UnsuspendAction:
+		if err := s.auth.LiftSuspension(ctx, target.SubjectId); err != nil {
+			log.WithError(err).WithField("userId", target.SubjectId).Warn("can not cache lifted suspension")
+		}
+	}
+	return action, nil
+}
+
+// recordDecision writes a decision that does not change the target
+func (s *ModerationService) recordDecision(ctx context.Context, record func(tx *bun.Tx) error) error {
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetActions pages through the moderation audit log
+func (s *ModerationService) GetActions(ctx context.Context, userId string, filter model.ModerationActionFilter, limit, offset int) (any, error) {
+	if err := s.requireModerator(ctx, userId); err != nil {
+		return nil, err
+	}
+	actions, err := s.repo.GetActions(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &map[string]any{
+		"data":   actions,
+		"limit":  limit,
+		"offset": offset,
+	}, nil
+}
+
+// ModeratePost takes a post down or restores it. A hidden post disappears
+// from every feed, search and profile, its author included.
+func (s *NewsfeedService) ModeratePost(ctx context.Context, postId string, hidden bool, audit func(tx *bun.Tx) error) error {
+	post, err := s.repo.GetModeratedPost(ctx, postId)
+	if err != nil {
+		return errors.New("this post was not found")
+	}
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = s.repo.SetPostModeratedTransaction(ctx, tx, postId, hidden); err != nil {
+		return err
+	}
+	if audit != nil {
+		if err = audit(tx); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	if hidden {
+		logSearchError("removal", s.search.RemovePost(ctx, postId))
+	} else {
+		logSearchError("index", s.search.IndexPost(ctx, post))
+	}
+	// Timelines keep the id, hydration drops hidden posts
+	s.invalidatePost(ctx, post)
+	return nil
+}
+
+// ModerateComment hides a comment or restores it, like its post's author can
+func (s *NewsfeedService) ModerateComment(ctx context.Context, commentId string, hidden bool, audit func(tx *bun.Tx) error) error {
+	comment, err := s.repo.GetComment(ctx, commentId)
+	if err != nil || comment.Status == model.DeletedComment {
+		return errors.New("this comment was not found")
+	}
+	status := model.ActiveComment
+	if hidden {
+		status = model.HiddenComment
+	}
+	if comment.Status == status {
+		if hidden {
+			return errors.New("this comment is already hidden")
+		}
+		return errors.New("this comment is not hidden")
+	}
+	return s.setCommentStatus(ctx, comment, status, audit)
+}
@@ -4,33 +4,125 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
 	"program/internal/model"
 	newsfeedRepo "program/internal/repositories/newfeed"
+	searchRepo "program/internal/repositories/search"
+	timelineRepo "program/internal/repositories/timeline"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
 )
 
 type INewsfeedService interface {
 	CreatePost(ctx context.Context, user_id string, post *model.NewsfeedPost) (any, error)
-	GetNewsfeed(ctx context.Context, limit, offset int, user_id string) (any, error)
-	ToggleLikePost(ctx context.Context, userId, postId string) error
-	GetLikers(ctx context.Context, limit, offset int, userId, post_id string, isGuestUser bool) (any, error)
+	GetNewsfeed(ctx context.Context, limit, offset int, user_id string, mode model.FeedMode, cursor string, span model.FeedRange) (any, error)
+	ToggleLikePost(ctx context.Context, userId, postId string, reaction model.Reaction) error
+	ToggleLikeComment(ctx context.Context, userId, commentId string) error
+	GetLikers(ctx context.Context, limit, offset int, userId, post_id string, reaction model.Reaction, isGuestUser bool) (any, error)
 	PostComment(ctx context.Context, user_id, post_id string, comment *model.CommentPost) (any, error)
-	GetComments(ctx context.Context, limit, offset int, post_id string) (any, error)
-	PutComment(ctx context.Context, commentPut *model.CommentPut) (any, error)
+	GetComments(ctx context.Context, limit, offset int, user_id, post_id string) (any, error)
+	GetReplies(ctx context.Context, limit, offset int, user_id, post_id, comment_id string) (any, error)
+	PutComment(ctx context.Context, userId, postId string, commentPut *model.CommentPut) (any, error)
+	DeleteComment(ctx context.Context, userId, postId, commentId string) error
+	GetCommentRevisions(ctx context.Context, userId, postId, commentId string) (any, error)
+	SetCommentHidden(ctx context.Context, userId, postId, commentId string, hidden bool) error
+	UpdatePost(ctx context.Context, userId, postId string, patch *model.PostPatch) (any, error)
+	DeletePost(ctx context.Context, userId, postId string) error
+	GetPostRevisions(ctx context.Context, userId, postId string) (any, error)
+	SharePost(ctx context.Context, userId, postId string, share *model.SharePost) (any, error)
+	UploadMedia(ctx *gin.Context, userId string, fileUploaded *multipart.FileHeader, altText string) (any, error)
+	GetHashtagFeed(ctx context.Context, limit, offset int, userId, tag string) (any, error)
+	GetTrendingTags(ctx context.Context, limit int) (any, error)
+	GetPostsByIds(ctx context.Context, userId string, postIds []string) (*[]model.NewsFeed, error)
+	GetDrafts(ctx context.Context, limit, offset int, userId string) (any, error)
+	PublishPost(ctx context.Context, userId, postId string) (any, error)
+	PublishDuePosts(ctx context.Context) (int, error)
+	VotePoll(ctx context.Context, userId, postId string, vote *model.PollVotePost) (any, error)
+	SetPostPinned(ctx context.Context, userId, postId string, pinned bool) error
+	GetUserPosts(ctx context.Context, limit, offset int, userId, authorId string) (any, error)
+	ModeratePost(ctx context.Context, postId string, hidden bool, audit func(tx *bun.Tx) error) error
+	ModerateComment(ctx context.Context, commentId string, hidden bool, audit func(tx *bun.Tx) error) error
 }
+
+// NewsfeedCacheConfig controls the read-through newsfeed cache. Pages only
+// hold post ids per viewer, post payloads are shared between viewers.
+type NewsfeedCacheConfig struct {
+	PageTTL time.Duration
+	PostTTL time.Duration
+}
+
 type NewsfeedService struct {
-	repo newsfeedRepo.INewsfeedRepo
+	repo      newsfeedRepo.INewsfeedRepo
+	timeline  ITimelineService
+	feedCache timelineRepo.ITimelineRepo
+	ranker    IFeedRanker
+	storage   IFileStorage
+	notifier  INotificationService
+	search    searchRepo.ISearchRepo
+	links     ILinkPreviewWorker
+	filters   IContentFilterPipeline
+	config    NewsfeedConfig
+}
+
+// MediaConfig limits post attachments. AllowedTypes maps the accepted MIME
+// types to the file extension they are stored with.
+type MediaConfig struct {
+	MaxPerPost   int
+	MaxSizeBytes int64
+	AllowedTypes map[string]string
+}
+
+var DefaultMediaTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Number of replies returned with each top-level comment
+const replyPreviewSize = 3
+
+// Number of scheduled posts published per scheduler run
+const publishBatchSize = 100
+
+type NewsfeedConfig struct {
+	Cache          NewsfeedCacheConfig
+	Ranking        RankingConfig
+	Media          MediaConfig
+	TrendingWindow time.Duration
 }
 
-func NewNewsFeedService(repo newsfeedRepo.INewsfeedRepo) INewsfeedService {
+func NewNewsFeedService(repo newsfeedRepo.INewsfeedRepo, timeline ITimelineService, feedCache timelineRepo.ITimelineRepo, ranker IFeedRanker, storage IFileStorage, notifier INotificationService, search searchRepo.ISearchRepo, links ILinkPreviewWorker, filters IContentFilterPipeline, config NewsfeedConfig) INewsfeedService {
 	return &NewsfeedService{
-		repo: repo,
+		repo:      repo,
+		timeline:  timeline,
+		feedCache: feedCache,
+		ranker:    ranker,
+		storage:   storage,
+		notifier:  notifier,
+		search:    search,
+		links:     links,
+		filters:   filters,
+		config:    config,
 	}
 }
 
 func (s *NewsfeedService) CreatePost(ctx context.Context, userId string, post *model.NewsfeedPost) (any, error) {
+	if len(post.MediaIds) > s.config.Media.MaxPerPost {
+		return nil, fmt.Errorf("a post can have at most %d media", s.config.Media.MaxPerPost)
+	}
 	newpost := &model.Post{
 		PostId:       uuid.NewString(),
 		UserId:       userId,
@@ -39,47 +131,188 @@ func (s *NewsfeedService) CreatePost(ctx context.Context, userId string, post *m
 		LikeCount:    0,
 		CommentCount: 0,
 		ShareCount:   0,
+		Status:       model.PublishedPost,
 		Deleted:      0,
 		CreatedAt:    time.Now(),
 	}
-	mypost, err := s.repo.CreatePost(ctx, newpost)
-	return mypost, err
-}
+	switch {
+	case post.Draft && post.PublishAt != nil:
+		return nil, errors.New("a draft can not be scheduled")
+	case post.Draft:
+		newpost.Status = model.DraftPost
+	case post.PublishAt != nil && post.PublishAt.After(newpost.CreatedAt):
+		newpost.Status = model.ScheduledPost
+		newpost.PublishAt = post.PublishAt
+	}
+	newpost.Type = model.TextPostType
+	var poll *model.Poll
+	var options []model.PollOption
+	if post.Poll != nil {
+		newpost.Type = model.PollPostType
+		var err error
+		if poll, options, err = newPoll(newpost, post.Poll); err != nil {
+			return nil, err
+		}
+	}
+	audience, err := s.checkAudience(ctx, userId, newpost.Privacy, post.AudienceListIds)
+	if err != nil {
+		return nil, err
+	}
+	filterInput := &FilterInput{UserId: userId, Target: model.ReportedPost, Content: newpost.Content}
+	verdict, err := s.filterContent(ctx, filterInput)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := s.resolveMentions(ctx, model.MentionPost, newpost.PostId, newpost.Content)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = s.repo.CreatePostTransaction(ctx, tx, newpost); err != nil {
+		return nil, err
+	}
+	if err = s.repo.SetPostAudiencesTransaction(ctx, tx, newpost.PostId, audience); err != nil {
+		return nil, err
+	}
+	if err = s.repo.AttachMediaTransaction(ctx, tx, userId, newpost.PostId, post.MediaIds); err != nil {
+		return nil, err
+	}
+	if poll != nil {
+		if err = s.repo.CreatePollTransaction(ctx, tx, poll, options); err != nil {
+			return nil, err
+		}
+	}
+	tags := ExtractHashtags(newpost.Content)
+	if err = s.repo.SetPostHashtagsTransaction(ctx, tx, newpost.PostId, tags); err != nil {
+		return nil, err
+	}
+	if err = s.repo.SetMentionsTransaction(ctx, tx, model.MentionPost, newpost.PostId, mentions); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.filters.Record(ctx, filterInput)
+	s.filters.Flag(ctx, newpost.PostId, filterInput, verdict)
+	if ExtractLink(newpost.Content) != "" {
+		s.links.Enqueue(newpost.PostId, newpost.Content)
+	}
+	if newpost.Status != model.PublishedPost {
+		// Nobody else sees it until it is published, see publishPost
+		return newpost, nil
+	}
+	s.afterPublish(ctx, newpost, tags, mentions)
 
-func (s *NewsfeedService) PostComment(ctx context.Context, user_id, post_id string, comment *model.CommentPost) (any, error) {
-	newcomment := &model.Comment{
-		CommentId:  uuid.NewString(),
-		UserId:     user_id,
-		PostId:     post_id,
-		ParentId:   nil,
-		LikeCount:  0,
-		ReplyCount: 0,
-		Content:    comment.Content,
-		Status:     model.ActiveComment,
-		CreatedAt:  time.Now(),
+	posts, err := s.loadPosts(ctx, userId, []string{newpost.PostId})
+	if err != nil {
+		return nil, err
+	}
+	if len(*posts) == 0 {
+		return nil, errors.New("can not load the new post")
 	}
+	return &(*posts)[0], nil
+}
 
-	if comment.Parent != "" {
-		//Check parent is existed
-		newcomment.ParentId = &comment.Parent
+// afterPublish runs the side effects of a post going live: trending tags,
+// mention notifications, search indexing and the fan-out to followers.
+func (s *NewsfeedService) afterPublish(ctx context.Context, post *model.Post, tags []string, mentions []model.Mention) {
+	if post.Privacy == model.Public {
+		s.countTrendingTags(ctx, tags)
 	}
-	mycomment, err := s.repo.CreateComment(ctx, newcomment)
-	return mycomment, err
+	s.notifyMentioned(ctx, post.UserId, post.PostId, nil, mentions, nil)
+	logSearchError("index", s.search.IndexPost(ctx, post))
+	logTimelineError("fan-out", s.timeline.FanOutPost(ctx, post))
+	logTimelineError("cache invalidation", s.timeline.InvalidateAudience(ctx, post.UserId))
 }
 
-func (s *NewsfeedService) GetNewsfeed(ctx context.Context, limit, offset int, userId string) (any, error) {
-	cacheKey := fmt.Sprintf("newsfeed:user:%s", userId)
+// GetDrafts lists the drafts and scheduled posts of a user, only ever to
+// that user.
+func (s *NewsfeedService) GetDrafts(ctx context.Context, limit, offset int, userId string) (any, error) {
+	drafts, err := s.repo.GetDrafts(ctx, userId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachPostDetails(ctx, *drafts); err != nil {
+		return nil, err
+	}
+	return drafts, nil
+}
 
-	newsfeed, err := s.repo.GetNewsfeed(ctx, limit, offset, userId)
+// PublishPost publishes a draft or a scheduled post right away
+func (s *NewsfeedService) PublishPost(ctx context.Context, userId, postId string) (any, error) {
+	post, err := s.repo.GetPost(ctx, postId)
+	if err != nil || (post.UserId != userId && post.Status != model.PublishedPost) {
+		return nil, errors.New("this post was not found")
+	}
+	if post.UserId != userId {
+		return nil, errors.New("you don't have permission to publish this post")
+	}
+	if post.Status == model.PublishedPost {
+		return nil, errors.New("this post is already published")
+	}
+	if err := s.publishPost(ctx, post, time.Now()); err != nil {
+		return nil, err
+	}
+	posts, err := s.GetPostsByIds(ctx, userId, []string{postId})
 	if err != nil {
 		return nil, err
 	}
-	s.repo.SaveNewsfeedCache(ctx, cacheKey, newsfeed)
+	if len(*posts) == 0 {
+		return nil, errors.New("can not load the published post")
+	}
+	return &(*posts)[0], nil
+}
 
-	return newsfeed, nil
+// PublishDuePosts publishes the scheduled posts whose time has come and
+// returns how many were published. A post published concurrently by its
+// author is skipped, a poll that closed meanwhile goes back to the drafts.
+func (s *NewsfeedService) PublishDuePosts(ctx context.Context) (int, error) {
+	due, err := s.repo.GetDuePosts(ctx, time.Now(), publishBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	for i := range due {
+		post := &due[i]
+		if err := s.publishPost(ctx, post, *post.PublishAt); err != nil {
+			log.WithError(err).WithField("postId", post.PostId).Warn("scheduled post not published")
+			// It would fail on every tick and hold back the posts due after it
+			if errors.Is(err, errPollClosed) {
+				if err := s.repo.UnschedulePost(ctx, post.PostId); err != nil {
+					log.WithError(err).WithField("postId", post.PostId).Warn("can not move scheduled post back to drafts")
+				}
+			}
+			continue
+		}
+		published++
+	}
+	return published, nil
 }
 
-func (s *NewsfeedService) ToggleLikePost(ctx context.Context, userId, postId string) error {
+// publishPost makes a post live with publishedAt as its creation time, so
+// that it is placed in timelines as if it had just been posted.
+func (s *NewsfeedService) publishPost(ctx context.Context, post *model.Post, publishedAt time.Time) error {
+	if post.Type == model.PollPostType {
+		if err := s.checkPollOpen(ctx, post.PostId, publishedAt); err != nil {
+			return err
+		}
+	}
+	tags, err := s.repo.GetPostHashtags(ctx, post.PostId)
+	if err != nil {
+		return err
+	}
+	mentions, err := s.repo.GetMentions(ctx, model.MentionPost, []string{post.PostId})
+	if err != nil {
+		return err
+	}
 	tx, err := s.repo.GetDBTx(ctx)
 	if err != nil {
 		return err
@@ -89,106 +322,1460 @@ func (s *NewsfeedService) ToggleLikePost(ctx context.Context, userId, postId str
 			tx.Rollback()
 		}
 	}()
-	postExisted, err := s.repo.IsPostExisted(ctx, postId)
-	if err != nil {
+	if err = s.repo.PublishPostTransaction(ctx, tx, post.PostId, publishedAt); err != nil {
 		return err
 	}
-	if !postExisted {
-		return errors.New("this post was not found")
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	post.Status = model.PublishedPost
+	post.PublishAt = nil
+	post.CreatedAt = publishedAt
+	s.afterPublish(ctx, post, tags, mentions)
+	return nil
+}
+
+// UploadMedia stores an image or video that can then be attached to a post by
+// its id. The type is sniffed from the content, not taken from the request.
+func (s *NewsfeedService) UploadMedia(ctx *gin.Context, userId string, fileUploaded *multipart.FileHeader, altText string) (any, error) {
+	if fileUploaded.Size > s.config.Media.MaxSizeBytes {
+		return nil, fmt.Errorf("media can not be larger than %d bytes", s.config.Media.MaxSizeBytes)
 	}
-	likeExisted, err := s.repo.IsLikeExisted(ctx, postId, userId)
+	file, err := fileUploaded.Open()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if !likeExisted {
-		newlike := &model.Like{
-			LikeId:    uuid.NewString(),
-			PostId:    postId,
-			UserId:    userId,
-			Type:      model.LikePost,
-			IsActive:  true,
-			CreatedAt: time.Now(),
+	defer file.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	mimeType := http.DetectContentType(head[:n])
+	ext, allowed := s.config.Media.AllowedTypes[mimeType]
+	if !allowed {
+		return nil, errors.New("unsupported media type")
+	}
+
+	media := &model.PostMedia{
+		MediaId:   uuid.NewString(),
+		UserId:    userId,
+		MimeType:  mimeType,
+		SizeBytes: fileUploaded.Size,
+		AltText:   altText,
+		CreatedAt: time.Now(),
+	}
+	if strings.HasPrefix(mimeType, "image/") {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
 		}
-		err := s.repo.CreateLike(ctx, tx, newlike)
+		config, _, err := image.DecodeConfig(file)
 		if err != nil {
-			return err
+			return nil, errors.New("can not read image dimensions")
 		}
-		err = s.repo.IncreaseLikeCount(ctx, tx, postId)
-		if err != nil {
-			return err
+		media.Width, media.Height = config.Width, config.Height
+	}
+
+	path, err := s.storage.Save(ctx, fileUploaded, "media", media.MediaId+ext)
+	if err != nil {
+		return nil, err
+	}
+	media.Url = "/" + filepath.ToSlash(path)
+	if err := s.repo.CreateMedia(ctx, media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+func (s *NewsfeedService) UpdatePost(ctx context.Context, userId, postId string, patch *model.PostPatch) (any, error) {
+	post, err := s.repo.GetPost(ctx, postId)
+	if err != nil {
+		return nil, errors.New("this post was not found")
+	}
+	if post.UserId != userId {
+		return nil, errors.New("you don't have permission to modify this post")
+	}
+	revision := &model.PostRevision{
+		RevisionId: uuid.NewString(),
+		PostId:     postId,
+		Content:    post.Content,
+		Privacy:    post.Privacy,
+		CreatedAt:  time.Now(),
+	}
+	fields := make(map[string]any)
+	if patch.Content != nil {
+		fields["content"] = *patch.Content
+		post.Content = *patch.Content
+	}
+	wasCustom := post.Privacy == model.Custom
+	if patch.Privacy != nil {
+		post.Privacy = *patch.Privacy
+		// A repost can not be opened wider than its original allows, making
+		// it private is always fine
+		if post.SharedPostId != nil && post.Privacy != model.Private {
+			// GetPost gives no post when the original was deleted since
+			original, _ := s.repo.GetPost(ctx, *post.SharedPostId)
+			if err := checkSharePrivacy(original, post.Privacy); err != nil {
+				return nil, err
+			}
 		}
-	} else {
-		isActive, err := s.repo.IsActiveLike(ctx, postId, userId)
-		if err != nil {
-			return err
+		fields["privacy"] = *patch.Privacy
+		// A private post would stay pinned where only its author sees it
+		if post.Privacy == model.Private && post.PinnedAt != nil {
+			fields["pinnedAt"] = nil
 		}
-		if isActive {
-			err = s.repo.UpdateLikeTransaction(ctx, tx, userId, postId, false)
-			if err != nil {
-				return err
-			}
-			err = s.repo.DecreaseLikeCount(ctx, tx, postId)
-			if err != nil {
-				return err
-			}
-		} else {
-			err = s.repo.UpdateLikeTransaction(ctx, tx, userId, postId, true)
-			if err != nil {
-				return err
-			}
-			err = s.repo.IncreaseLikeCount(ctx, tx, postId)
-			if err != nil {
-				return err
+	}
+	// Audience lists are replaced when given, kept when a custom post stays
+	// custom and dropped when it stops being custom
+	var audience []string
+	audienceChanged := patch.AudienceListIds != nil || (post.Privacy == model.Custom) != wasCustom
+	if audienceChanged {
+		if audience, err = s.checkAudience(ctx, userId, post.Privacy, patch.AudienceListIds); err != nil {
+			return nil, err
+		}
+	}
+	published := post.Status == model.PublishedPost
+	if patch.PublishAt != nil {
+		if published {
+			return nil, errors.New("a published post can not be rescheduled")
+		}
+		if !patch.PublishAt.After(time.Now()) {
+			return nil, errors.New("publishAt must be in the future")
+		}
+		if post.Type == model.PollPostType {
+			if err := s.checkPollOpen(ctx, postId, *patch.PublishAt); err != nil {
+				return nil, err
 			}
 		}
+		fields["status"] = model.ScheduledPost
+		fields["publishAt"] = *patch.PublishAt
 	}
-	err = tx.Commit()
-	if err != nil {
-		return err
+	if len(fields) == 0 && !audienceChanged {
+		return nil, errors.New("no fields to update")
 	}
-	return nil
-}
+	// Unpublished posts have no audience yet, they are edited in place
+	if published {
+		fields["edited"] = true
+	}
+	fields["updatedAt"] = revision.CreatedAt
 
-func (s *NewsfeedService) GetLikers(ctx context.Context, limit, offset int, userId, post_id string, isGuestUser bool) (any, error) {
-	if isGuestUser {
-		err := s.repo.CheckPublicPrivacyPermission(ctx, post_id)
+	var oldTags, newTags []string
+	var oldMentions, newMentions []model.Mention
+	var filterInput *FilterInput
+	var verdict *FilterResult
+	if patch.Content != nil {
+		filterInput = &FilterInput{UserId: userId, Target: model.ReportedPost, Content: post.Content, Edit: true}
+		if verdict, err = s.filterContent(ctx, filterInput); err != nil {
+			return nil, err
+		}
+		oldTags, err = s.repo.GetPostHashtags(ctx, postId)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		err := s.repo.CheckFriendPrivacyPermission(ctx, userId, post_id)
+		newTags = ExtractHashtags(post.Content)
+		oldMentions, err = s.repo.GetMentions(ctx, model.MentionPost, []string{postId})
+		if err != nil {
+			return nil, err
+		}
+		newMentions, err = s.resolveMentions(ctx, model.MentionPost, postId, post.Content)
 		if err != nil {
 			return nil, err
 		}
 	}
-	likers, err := s.repo.GetLikers(ctx, limit, offset, post_id)
+	tx, err := s.repo.GetDBTx(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return likers, nil
-}
-
-func (s *NewsfeedService) GetComments(ctx context.Context, limit, offset int, post_id string) (any, error) {
-	comments, err := s.repo.GetComments(ctx, limit, offset, post_id)
-	if err != nil {
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if published {
+		if err = s.repo.CreatePostRevision(ctx, tx, revision); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.repo.UpdatePost(ctx, tx, postId, fields); err != nil {
+		return nil, err
+	}
+	if audienceChanged {
+		if err = s.repo.SetPostAudiencesTransaction(ctx, tx, postId, audience); err != nil {
+			return nil, err
+		}
+	}
+	if patch.Content != nil {
+		if err = s.repo.SetPostHashtagsTransaction(ctx, tx, postId, newTags); err != nil {
+			return nil, err
+		}
+		if err = s.repo.SetMentionsTransaction(ctx, tx, model.MentionPost, postId, newMentions); err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
 		return nil, err
 	}
-	fmt.Println(comments)
+	if patch.Content != nil {
+		s.filters.Record(ctx, filterInput)
+		s.filters.Flag(ctx, postId, filterInput, verdict)
+		s.links.Enqueue(postId, post.Content)
+	}
+	if !published {
+		return &map[string]any{
+			"post_id": postId,
+			"message": "modify post successfully",
+		}, nil
+	}
+	if post.Privacy == model.Public {
+		s.countTrendingTags(ctx, tagsAdded(oldTags, newTags))
+	}
+	s.notifyMentioned(ctx, userId, postId, nil, newMentions, oldMentions)
+	if patch.Content != nil {
+		logSearchError("index", s.search.IndexPost(ctx, post))
+	}
+	s.invalidatePost(ctx, post)
+	if patch.Privacy != nil || audienceChanged {
+		// Followers who could not see the post before need it in their timeline
+		logTimelineError("fan-out", s.timeline.FanOutPost(ctx, post))
+	}
 	return &map[string]any{
-		"post_id": post_id,
-		"data":    comments,
-		"limit":   limit,
-		"offset":  offset,
+		"post_id": postId,
+		"message": "modify post successfully",
 	}, nil
 }
 
-func (s *NewsfeedService) PutComment(ctx context.Context, commentPut *model.CommentPut) (any, error) {
-	err := s.repo.PutComment(ctx, commentPut.CommentId, commentPut.Content)
+func (s *NewsfeedService) DeletePost(ctx context.Context, userId, postId string) error {
+	post, err := s.repo.GetPost(ctx, postId)
+	if err != nil {
+		return errors.New("this post was not found")
+	}
+	if post.UserId != userId {
+		return errors.New("you don't have permission to delete this post")
+	}
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = s.repo.DeletePost(ctx, tx, postId); err != nil {
+		return err
+	}
+	if post.SharedPostId != nil {
+		if err = s.repo.DecreaseShareCount(ctx, tx, *post.SharedPostId); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	// Timelines keep the id, hydration drops deleted posts
+	logSearchError("removal", s.search.RemovePost(ctx, postId))
+	s.invalidatePost(ctx, post)
+	if post.SharedPostId != nil {
+		logTimelineError("cache invalidation", s.feedCache.DeleteCachedPost(ctx, *post.SharedPostId))
+	}
+	return nil
+}
+
+// checkSharePrivacy applies the sharing rules of original to the privacy of a
+// repost, both when it is shared and when its privacy is edited. original is
+// nil when it was deleted since.
+func checkSharePrivacy(original *model.Post, privacy model.Privacy) error {
+	switch {
+	case privacy == model.Custom:
+		return errors.New("shared posts can not be limited to audience lists")
+	case original == nil:
+		return nil
+	case original.Privacy == model.Private:
+		return errors.New("private posts can not be shared")
+	case original.Privacy == model.Custom:
+		return errors.New("posts shared with audience lists can not be shared")
+	case original.Privacy == model.Friends && privacy == model.Public:
+		return errors.New("friends-only posts can not be shared publicly")
+	}
+	return nil
+}
+
+// SharePost reposts postId, optionally with a quote. Sharing a repost shares
+// its original. The repost can not be more visible than the original:
+// private posts can not be shared at all and friends-only posts can not be
+// shared publicly.
+func (s *NewsfeedService) SharePost(ctx context.Context, userId, postId string, share *model.SharePost) (any, error) {
+	original, err := s.repo.GetPost(ctx, postId)
+	if err != nil || original.Status != model.PublishedPost {
+		return nil, errors.New("this post was not found")
+	}
+	if original.SharedPostId != nil {
+		original, err = s.repo.GetPost(ctx, *original.SharedPostId)
+		if err != nil {
+			return nil, errors.New("the original post was not found")
+		}
+	}
+	if err := s.repo.CheckFriendPrivacyPermission(ctx, userId, original.PostId); err != nil {
+		return nil, errors.New("you don't have permission to share this post")
+	}
+	if err := checkSharePrivacy(original, share.Privacy); err != nil {
+		return nil, err
+	}
+
+	// A share may come without a comment of its own
+	var filterInput *FilterInput
+	verdict := &FilterResult{Verdict: FilterAllow}
+	if strings.TrimSpace(share.Content) != "" {
+		filterInput = &FilterInput{UserId: userId, Target: model.ReportedPost, Content: share.Content}
+		if verdict, err = s.filterContent(ctx, filterInput); err != nil {
+			return nil, err
+		}
+	}
+	repost := &model.Post{
+		PostId:       uuid.NewString(),
+		UserId:       userId,
+		Content:      share.Content,
+		Privacy:      share.Privacy,
+		SharedPostId: &original.PostId,
+		Status:       model.PublishedPost,
+		Deleted:      0,
+		CreatedAt:    time.Now(),
+	}
+	tx, err := s.repo.GetDBTx(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return &map[string]any{
-		"comment_id": commentPut.CommentId,
-		"message":    "modify comment successfully",
-	}, nil
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = s.repo.CreatePostTransaction(ctx, tx, repost); err != nil {
+		return nil, err
+	}
+	if err = s.repo.IncreaseShareCount(ctx, tx, original.PostId); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	if filterInput != nil {
+		s.filters.Record(ctx, filterInput)
+		s.filters.Flag(ctx, repost.PostId, filterInput, verdict)
+	}
+	if ExtractLink(repost.Content) != "" {
+		s.links.Enqueue(repost.PostId, repost.Content)
+	}
+
+	logTimelineError("cache invalidation", s.feedCache.DeleteCachedPost(ctx, original.PostId))
+	logTimelineError("fan-out", s.timeline.FanOutPost(ctx, repost))
+	logTimelineError("cache invalidation", s.timeline.InvalidateAudience(ctx, userId))
+
+	posts, err := s.loadPosts(ctx, userId, []string{repost.PostId})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachSharedPosts(ctx, userId, *posts); err != nil {
+		return nil, err
+	}
+	if len(*posts) == 0 {
+		return nil, errors.New("can not load the shared post")
+	}
+	return &(*posts)[0], nil
+}
+
+// filterContent runs the content filters, rejected content is returned as an
+// error. Once stored, the content must be passed to Record and Flag.
+func (s *NewsfeedService) filterContent(ctx context.Context, input *FilterInput) (*FilterResult, error) {
+	result, err := s.filters.Check(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if result.Verdict == FilterReject {
+		return nil, errors.New(result.Detail)
+	}
+	return result, nil
+}
+
+// invalidatePost drops the shared payload of a post and the cached pages of
+// everyone who may have it in their feed.
+func (s *NewsfeedService) invalidatePost(ctx context.Context, post *model.Post) {
+	logTimelineError("cache invalidation", s.feedCache.DeleteCachedPost(ctx, post.PostId))
+	logTimelineError("cache invalidation", s.timeline.InvalidateAudience(ctx, post.UserId))
+}
+
+func (s *NewsfeedService) PostComment(ctx context.Context, user_id, post_id string, comment *model.CommentPost) (any, error) {
+	newcomment := &model.Comment{
+		CommentId:  uuid.NewString(),
+		UserId:     user_id,
+		PostId:     post_id,
+		ParentId:   nil,
+		LikeCount:  0,
+		ReplyCount: 0,
+		Content:    comment.Content,
+		Status:     model.ActiveComment,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.checkVisible(ctx, user_id, post_id); err != nil {
+		return nil, err
+	}
+	filterInput := &FilterInput{UserId: user_id, Target: model.ReportedComment, Content: newcomment.Content}
+	verdict, err := s.filterContent(ctx, filterInput)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Parent != "" {
+		parent, err := s.repo.GetComment(ctx, comment.Parent)
+		if err != nil || parent.PostId != post_id || parent.Status != model.ActiveComment {
+			return nil, errors.New("parent comment was not found")
+		}
+		// Threads are one level deep, a reply to a reply joins its thread
+		newcomment.ParentId = &parent.CommentId
+		if parent.ParentId != nil {
+			newcomment.ParentId = parent.ParentId
+		}
+	}
+	mentions, err := s.resolveMentions(ctx, model.MentionComment, newcomment.CommentId, newcomment.Content)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	mycomment, err := s.repo.CreateComment(ctx, tx, newcomment)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repo.IncreaseCommentCount(ctx, tx, post_id); err != nil {
+		return nil, err
+	}
+	if newcomment.ParentId != nil {
+		if err = s.repo.IncreaseReplyCount(ctx, tx, *newcomment.ParentId); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.repo.SetMentionsTransaction(ctx, tx, model.MentionComment, newcomment.CommentId, mentions); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	mycomment.Mentions = mentions
+	s.filters.Record(ctx, filterInput)
+	s.filters.Flag(ctx, newcomment.CommentId, filterInput, verdict)
+	logTimelineError("cache invalidation", s.feedCache.DeleteCachedPost(ctx, post_id))
+	s.notifyMentioned(ctx, user_id, post_id, &newcomment.CommentId, mentions, nil)
+	return mycomment, nil
+}
+
+// resolveMentions parses the @usernames of content and keeps those matching
+// an account, ready to be stored for the given target.
+func (s *NewsfeedService) resolveMentions(ctx context.Context, targetType model.MentionTarget, targetId, content string) ([]model.Mention, error) {
+	parsed := ExtractMentions(content)
+	if len(parsed) == 0 {
+		return parsed, nil
+	}
+	usernames := make([]string, 0, len(parsed))
+	for _, mention := range parsed {
+		usernames = append(usernames, mention.Username)
+	}
+	users, err := s.repo.ResolveUsernames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+	userIds := make(map[string]string, len(users))
+	for _, user := range users {
+		userIds[strings.ToLower(user.Username)] = user.UserUuid
+	}
+	mentions := make([]model.Mention, 0, len(parsed))
+	for _, mention := range parsed {
+		userId, ok := userIds[strings.ToLower(mention.Username)]
+		if !ok {
+			continue
+		}
+		mention.MentionId = uuid.NewString()
+		mention.TargetType = targetType
+		mention.TargetId = targetId
+		mention.UserId = userId
+		mention.CreatedAt = time.Now()
+		mentions = append(mentions, mention)
+	}
+	return mentions, nil
+}
+
+// notifyMentioned notifies the users newly mentioned in a post or comment.
+// Users who can not see the post are skipped so that the notification does
+// not reveal that the post exists.
+func (s *NewsfeedService) notifyMentioned(ctx context.Context, actorId, postId string, commentId *string, mentions, previous []model.Mention) {
+	skip := map[string]bool{actorId: true}
+	for _, mention := range previous {
+		skip[mention.UserId] = true
+	}
+	userIds := make([]string, 0)
+	for _, mention := range mentions {
+		if !skip[mention.UserId] {
+			skip[mention.UserId] = true
+			userIds = append(userIds, mention.UserId)
+		}
+	}
+	if len(userIds) == 0 {
+		return
+	}
+	viewers, err := s.repo.GetPostViewers(ctx, postId, userIds)
+	if err != nil {
+		log.WithError(err).Warn("can not check mentioned users")
+		return
+	}
+	notificationType := model.MentionInPost
+	if commentId != nil {
+		notificationType = model.MentionInComment
+	}
+	notifications := make([]model.Notification, 0, len(viewers))
+	for _, userId := range viewers {
+		notifications = append(notifications, model.Notification{
+			UserId:    userId,
+			ActorId:   actorId,
+			Type:      notificationType,
+			PostId:    &postId,
+			CommentId: commentId,
+		})
+	}
+	s.notifier.Notify(ctx, notifications...)
+}
+
+// GetNewsfeed serves the feed page from the Redis cache when possible. Cached
+// pages were privacy-checked when they were filled, so their posts may come
+// from the shared payload cache; reactions and bookmarks are overlaid per
+// viewer.
+//
+// In ranked mode a larger pool of recent candidates is scored by the ranker
+// and the requested page is cut from the ranked pool.
+//
+// The timeline only holds the last few days of posts. The page on which it
+// runs out is completed with older posts read from MySQL and its NextCursor
+// continues from there. Requests with a cursor or a since/until range are
+// always served from MySQL, newest first, whatever the mode.
+func (s *NewsfeedService) GetNewsfeed(ctx context.Context, limit, offset int, userId string, mode model.FeedMode, cursor string, span model.FeedRange) (any, error) {
+	if cursor != "" || !span.IsZero() {
+		return s.getOlderFeed(ctx, limit, userId, cursor, span)
+	}
+
+	page := fmt.Sprintf("%s:%d:%d", mode, limit, offset)
+	postIds, hit, err := s.feedCache.GetFeedPage(ctx, userId, page)
+	if err != nil {
+		logTimelineError("cache read", err)
+		hit = false
+	}
+
+	var newsfeed *[]model.NewsFeed
+	nextCursor := ""
+	if hit {
+		newsfeed, err = s.getCachedPosts(ctx, userId, postIds)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// The timeline runs out on the page that reaches its end, even a full
+		// one, so one more entry is read to tell the last page from the others.
+		// Pages past the end stay empty, the client follows the cursor instead.
+		var entries []model.TimelineEntry
+		if mode == model.RankedFeed {
+			entries, err = s.timeline.GetTimelinePage(ctx, userId, s.config.Ranking.CandidatePool, 0)
+		} else if limit > 0 {
+			entries, err = s.timeline.GetTimelinePage(ctx, userId, limit+1, offset)
+		} else {
+			entries, err = s.timeline.GetTimelinePage(ctx, userId, limit, offset)
+		}
+		if err != nil {
+			return nil, err
+		}
+		exhausted := false
+		if mode != model.RankedFeed && limit > 0 {
+			exhausted = len(entries) <= limit && (len(entries) > 0 || offset == 0)
+			entries = entries[:min(limit, len(entries))]
+		}
+		postIds = make([]string, 0, len(entries))
+		for _, entry := range entries {
+			postIds = append(postIds, entry.PostId)
+		}
+		posts, err := s.loadPosts(ctx, userId, postIds)
+		if err != nil {
+			return nil, err
+		}
+		newsfeed = orderPostsByIds(*posts, postIds)
+
+		if mode == model.RankedFeed {
+			pool := len(*newsfeed)
+			exhausted = limit > 0 && offset+limit >= pool && (offset < pool || offset == 0)
+			newsfeed, err = s.rankPosts(ctx, userId, *newsfeed, limit, offset)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if exhausted {
+			var oldest *model.TimelineEntry
+			if len(entries) > 0 {
+				oldest = &entries[len(entries)-1]
+			}
+			newsfeed, nextCursor, err = s.completeFromArchive(ctx, userId, *newsfeed, oldest, limit)
+			if err != nil {
+				return nil, err
+			}
+		}
+		logTimelineError("cache write", s.feedCache.SaveCachedPosts(ctx, *newsfeed, s.config.Cache.PostTTL))
+
+		// The last page carries a cursor and reads MySQL, it is not cached
+		if !exhausted {
+			visibleIds := make([]string, 0, len(*newsfeed))
+			for _, post := range *newsfeed {
+				visibleIds = append(visibleIds, post.PostId)
+			}
+			logTimelineError("cache write", s.feedCache.SaveFeedPage(ctx, userId, page, visibleIds, s.config.Cache.PageTTL))
+		}
+	}
+
+	if err := s.attachSharedPosts(ctx, userId, *newsfeed); err != nil {
+		return nil, err
+	}
+	if err := s.overlayViewerState(ctx, userId, *newsfeed); err != nil {
+		return nil, err
+	}
+	return &model.FeedPage{Results: *newsfeed, NextCursor: nextCursor}, nil
+}
+
+// completeFromArchive fills the page up to limit with the posts older than
+// the oldest timeline entry, and returns the cursor of the next page.
+func (s *NewsfeedService) completeFromArchive(ctx context.Context, userId string, newsfeed []model.NewsFeed, oldest *model.TimelineEntry, limit int) (*[]model.NewsFeed, string, error) {
+	var before time.Time
+	beforeId := ""
+	if oldest != nil {
+		before, beforeId = oldest.CreatedAt, oldest.PostId
+	}
+	need := limit - len(newsfeed)
+	if need <= 0 {
+		if oldest == nil {
+			return &newsfeed, "", nil
+		}
+		return &newsfeed, encodeKeysetCursor(before, beforeId), nil
+	}
+	older, cursor, err := s.loadOlderPosts(ctx, userId, before, beforeId, model.FeedRange{}, need)
+	if err != nil {
+		return nil, "", err
+	}
+	newsfeed = append(newsfeed, older...)
+	return &newsfeed, cursor, nil
+}
+
+// getOlderFeed pages through the feed from MySQL, see GetOlderPostRefs
+func (s *NewsfeedService) getOlderFeed(ctx context.Context, limit int, userId, cursor string, span model.FeedRange) (any, error) {
+	before, beforeId, err := decodeKeysetCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	newsfeed, nextCursor, err := s.loadOlderPosts(ctx, userId, before, beforeId, span, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachSharedPosts(ctx, userId, newsfeed); err != nil {
+		return nil, err
+	}
+	if err := s.overlayViewerState(ctx, userId, newsfeed); err != nil {
+		return nil, err
+	}
+	return &model.FeedPage{Results: newsfeed, NextCursor: nextCursor}, nil
+}
+
+// loadOlderPosts hydrates one page of older posts. The cursor is empty when
+// the page came back short, at the end of the feed.
+func (s *NewsfeedService) loadOlderPosts(ctx context.Context, userId string, before time.Time, beforeId string, span model.FeedRange, limit int) ([]model.NewsFeed, string, error) {
+	refs, err := s.repo.GetOlderPostRefs(ctx, userId, before, beforeId, span.Since, span.Until, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	postIds := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		postIds = append(postIds, ref.PostId)
+	}
+	posts, err := s.loadPosts(ctx, userId, postIds)
+	if err != nil {
+		return nil, "", err
+	}
+	cursor := ""
+	if limit > 0 && len(refs) == limit {
+		last := refs[len(refs)-1]
+		cursor = encodeKeysetCursor(last.CreatedAt, last.PostId)
+	}
+	return *orderPostsByIds(*posts, postIds), cursor, nil
+}
+
+// rankPosts orders the candidate pool with the configured ranker and returns
+// the requested page of it.
+func (s *NewsfeedService) rankPosts(ctx context.Context, userId string, candidates []model.NewsFeed, limit, offset int) (*[]model.NewsFeed, error) {
+	authorIds := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, post := range candidates {
+		if !seen[post.UserId] {
+			seen[post.UserId] = true
+			authorIds = append(authorIds, post.UserId)
+		}
+	}
+	since := time.Now().AddDate(0, 0, -s.config.Ranking.AffinityDays)
+	affinity, err := s.repo.GetAuthorAffinity(ctx, userId, authorIds, since)
+	if err != nil {
+		return nil, err
+	}
+	mutual, err := s.repo.GetMutualFollowings(ctx, userId, authorIds)
+	if err != nil {
+		return nil, err
+	}
+	signals := &RankingSignals{
+		Affinity: make(map[string]int, len(affinity)),
+		Mutual:   make(map[string]bool, len(mutual)),
+	}
+	for _, a := range affinity {
+		signals.Affinity[a.AuthorId] = a.Interactions
+	}
+	for _, authorId := range mutual {
+		signals.Mutual[authorId] = true
+	}
+
+	ranked := s.ranker.Rank(userId, candidates, signals, time.Now())
+	if offset >= len(ranked) {
+		return &[]model.NewsFeed{}, nil
+	}
+	end := len(ranked)
+	if limit > 0 {
+		end = min(offset+limit, len(ranked))
+	}
+	ranked = ranked[offset:end]
+	return &ranked, nil
+}
+
+// getCachedPosts loads post payloads from the shared cache, hydrating and
+// caching the ones that expired.
+func (s *NewsfeedService) getCachedPosts(ctx context.Context, userId string, postIds []string) (*[]model.NewsFeed, error) {
+	cached, err := s.feedCache.GetCachedPosts(ctx, postIds)
+	if err != nil {
+		logTimelineError("cache read", err)
+		cached = map[string]model.NewsFeed{}
+	}
+	missing := make([]string, 0)
+	for _, postId := range postIds {
+		if _, ok := cached[postId]; !ok {
+			missing = append(missing, postId)
+		}
+	}
+	if len(missing) > 0 {
+		posts, err := s.loadPosts(ctx, userId, missing)
+		if err != nil {
+			return nil, err
+		}
+		logTimelineError("cache write", s.feedCache.SaveCachedPosts(ctx, *posts, s.config.Cache.PostTTL))
+		for _, post := range *posts {
+			cached[post.PostId] = post
+		}
+	}
+	posts := make([]model.NewsFeed, 0, len(cached))
+	for _, post := range cached {
+		posts = append(posts, post)
+	}
+	return orderPostsByIds(posts, postIds), nil
+}
+
+// GetHashtagFeed returns the posts tagged with tag that the viewer can see
+func (s *NewsfeedService) GetHashtagFeed(ctx context.Context, limit, offset int, userId, tag string) (any, error) {
+	postIds, err := s.repo.GetHashtagFeed(ctx, userId, NormalizeHashtag(tag), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPostsByIds(ctx, userId, postIds)
+}
+
+// GetPostsByIds hydrates postIds for the viewer in the given order, with
+// their shared originals and viewer state. Posts the viewer can not see are
+// dropped.
+func (s *NewsfeedService) GetPostsByIds(ctx context.Context, userId string, postIds []string) (*[]model.NewsFeed, error) {
+	posts, err := s.loadPosts(ctx, userId, postIds)
+	if err != nil {
+		return nil, err
+	}
+	newsfeed := orderPostsByIds(*posts, postIds)
+	if err := s.attachSharedPosts(ctx, userId, *newsfeed); err != nil {
+		return nil, err
+	}
+	if err := s.overlayViewerState(ctx, userId, *newsfeed); err != nil {
+		return nil, err
+	}
+	return newsfeed, nil
+}
+
+// GetTrendingTags ranks the tags used in public posts over the trending window
+func (s *NewsfeedService) GetTrendingTags(ctx context.Context, limit int) (any, error) {
+	return s.repo.GetTrendingTags(ctx, time.Now(), s.config.TrendingWindow, limit)
+}
+
+// countTrendingTags only ever receives tags of public posts, so trending
+// never reveals what is discussed in restricted posts.
+func (s *NewsfeedService) countTrendingTags(ctx context.Context, tags []string) {
+	if err := s.repo.IncrementTrendingTags(ctx, tags, time.Now(), s.config.TrendingWindow); err != nil {
+		log.WithError(err).Warn("trending tags update failed")
+	}
+}
+
+// tagsAdded returns the tags of newTags missing from oldTags
+func tagsAdded(oldTags, newTags []string) []string {
+	existing := make(map[string]bool, len(oldTags))
+	for _, tag := range oldTags {
+		existing[tag] = true
+	}
+	added := make([]string, 0)
+	for _, tag := range newTags {
+		if !existing[tag] {
+			added = append(added, tag)
+		}
+	}
+	return added
+}
+
+// loadPosts hydrates posts and their media for the viewer, see
+// GetPostsByIds for the visibility rules.
+func (s *NewsfeedService) loadPosts(ctx context.Context, userId string, postIds []string) (*[]model.NewsFeed, error) {
+	posts, err := s.repo.GetPostsByIds(ctx, userId, postIds)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachPostDetails(ctx, *posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// attachPostDetails loads the media, mentions and link preview of already
+// visible posts
+func (s *NewsfeedService) attachPostDetails(ctx context.Context, posts []model.NewsFeed) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.PostId)
+	}
+	media, err := s.repo.GetMediaForPosts(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byPost := make(map[string][]model.PostMedia)
+	for _, m := range media {
+		byPost[*m.PostId] = append(byPost[*m.PostId], m)
+	}
+	mentions, err := s.repo.GetMentions(ctx, model.MentionPost, ids)
+	if err != nil {
+		return err
+	}
+	mentionsByPost := make(map[string][]model.Mention)
+	for _, mention := range mentions {
+		mentionsByPost[mention.TargetId] = append(mentionsByPost[mention.TargetId], mention)
+	}
+	previews, err := s.repo.GetLinkPreviews(ctx, ids)
+	if err != nil {
+		return err
+	}
+	previewByPost := make(map[string]*model.LinkPreview, len(previews))
+	for i := range previews {
+		previewByPost[previews[i].PostId] = &previews[i]
+	}
+	for i := range posts {
+		posts[i].Media = byPost[posts[i].PostId]
+		posts[i].Mentions = mentionsByPost[posts[i].PostId]
+		posts[i].LinkPreview = previewByPost[posts[i].PostId]
+		posts[i].Pinned = posts[i].PinnedAt != nil
+	}
+	return nil
+}
+
+// attachSharedPosts embeds the originals of reposts. Originals are hydrated
+// for the viewer, so a repost of a post the viewer can not see is returned
+// without its original.
+func (s *NewsfeedService) attachSharedPosts(ctx context.Context, userId string, newsfeed []model.NewsFeed) error {
+	sharedIds := make([]string, 0)
+	for _, post := range newsfeed {
+		if post.SharedPostId != nil {
+			sharedIds = append(sharedIds, *post.SharedPostId)
+		}
+	}
+	if len(sharedIds) == 0 {
+		return nil
+	}
+	originals, err := s.loadPosts(ctx, userId, sharedIds)
+	if err != nil {
+		return err
+	}
+	if err := s.overlayViewerState(ctx, userId, *originals); err != nil {
+		return err
+	}
+	byId := make(map[string]*model.NewsFeed, len(*originals))
+	for i := range *originals {
+		byId[(*originals)[i].PostId] = &(*originals)[i]
+	}
+	for i := range newsfeed {
+		if newsfeed[i].SharedPostId != nil {
+			newsfeed[i].SharedPost = byId[*newsfeed[i].SharedPostId]
+		}
+	}
+	return nil
+}
+
+// overlayViewerState sets the viewer's own reaction, bookmarked flag and
+// poll state on each post
+func (s *NewsfeedService) overlayViewerState(ctx context.Context, userId string, newsfeed []model.NewsFeed) error {
+	postIds := make([]string, 0, len(newsfeed))
+	for _, post := range newsfeed {
+		postIds = append(postIds, post.PostId)
+	}
+	reactions, err := s.repo.GetReactions(ctx, userId, postIds)
+	if err != nil {
+		return err
+	}
+	byPost := make(map[string]model.Reaction, len(reactions))
+	for _, like := range reactions {
+		byPost[like.TargetId] = like.Reaction
+	}
+	bookmarked, err := s.repo.GetBookmarkedIds(ctx, userId, postIds)
+	if err != nil {
+		return err
+	}
+	polls, err := s.getPollStates(ctx, userId, newsfeed)
+	if err != nil {
+		return err
+	}
+	for i := range newsfeed {
+		newsfeed[i].Reaction = byPost[newsfeed[i].PostId]
+		newsfeed[i].Bookmarked = slices.Contains(bookmarked, newsfeed[i].PostId)
+		newsfeed[i].Poll = polls[newsfeed[i].PostId]
+	}
+	return nil
+}
+
+// orderPostsByIds returns posts in the order of postIds, skipping ids that
+// were not hydrated.
+func orderPostsByIds(posts []model.NewsFeed, postIds []string) *[]model.NewsFeed {
+	byId := make(map[string]model.NewsFeed, len(posts))
+	for _, post := range posts {
+		byId[post.PostId] = post
+	}
+	newsfeed := make([]model.NewsFeed, 0, len(postIds))
+	for _, postId := range postIds {
+		if post, ok := byId[postId]; ok {
+			newsfeed = append(newsfeed, post)
+		}
+	}
+	return &newsfeed
+}
+
+// ToggleLikePost reacts to a post. Reacting again with the current reaction
+// removes it, reacting with another one switches to it.
+func (s *NewsfeedService) ToggleLikePost(ctx context.Context, userId, postId string, reaction model.Reaction) error {
+	if !slices.Contains(model.Reactions, reaction) {
+		return errors.New("unknown reaction")
+	}
+	if err := s.checkVisible(ctx, userId, postId); err != nil {
+		return err
+	}
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	current, err := s.repo.GetLikeTransaction(ctx, tx, model.LikePost, postId, userId)
+	if err != nil {
+		return err
+	}
+	var added, removed model.Reaction
+	switch {
+	case current == nil:
+		added = reaction
+		err = s.repo.CreateLike(ctx, tx, &model.Like{
+			LikeId:    uuid.NewString(),
+			TargetId:  postId,
+			UserId:    userId,
+			Type:      model.LikePost,
+			Reaction:  reaction,
+			IsActive:  true,
+			CreatedAt: time.Now(),
+		})
+	case !current.IsActive:
+		added = reaction
+		err = s.repo.SetReactionTransaction(ctx, tx, userId, postId, reaction, true)
+	case current.Reaction == reaction:
+		removed = reaction
+		err = s.repo.SetReactionTransaction(ctx, tx, userId, postId, reaction, false)
+	default:
+		added, removed = reaction, current.Reaction
+		err = s.repo.SetReactionTransaction(ctx, tx, userId, postId, reaction, true)
+	}
+	if err != nil {
+		return err
+	}
+	if err = s.repo.UpdateReactionCounts(ctx, tx, postId, added, removed); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	logTimelineError("cache invalidation", s.feedCache.DeleteCachedPost(ctx, postId))
+	return nil
+}
+
+func (s *NewsfeedService) ToggleLikeComment(ctx context.Context, userId, commentId string) error {
+	comment, err := s.repo.GetComment(ctx, commentId)
+	if err != nil || comment.Status != model.ActiveComment {
+		return errors.New("this comment was not found")
+	}
+	if err := s.checkVisible(ctx, userId, comment.PostId); err != nil {
+		return err
+	}
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	liked, err := s.toggleLike(ctx, tx, userId, model.LikeComment, commentId)
+	if err != nil {
+		return err
+	}
+	if liked {
+		err = s.repo.IncreaseCommentLikeCount(ctx, tx, commentId)
+	} else {
+		err = s.repo.DecreaseCommentLikeCount(ctx, tx, commentId)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// toggleLike flips the like of userId on a comment and reports whether it is
+// now liked. The caller keeps the comment's likeCount in sync.
+func (s *NewsfeedService) toggleLike(ctx context.Context, tx *bun.Tx, userId string, likeType model.LikeType, targetId string) (bool, error) {
+	current, err := s.repo.GetLikeTransaction(ctx, tx, likeType, targetId, userId)
+	if err != nil {
+		return false, err
+	}
+	if current == nil {
+		newlike := &model.Like{
+			LikeId:    uuid.NewString(),
+			TargetId:  targetId,
+			UserId:    userId,
+			Type:      likeType,
+			Reaction:  model.ReactionLike,
+			IsActive:  true,
+			CreatedAt: time.Now(),
+		}
+		return true, s.repo.CreateLike(ctx, tx, newlike)
+	}
+	return !current.IsActive, s.repo.UpdateLikeTransaction(ctx, tx, userId, likeType, targetId, !current.IsActive)
+}
+
+func (s *NewsfeedService) GetLikers(ctx context.Context, limit, offset int, userId, post_id string, reaction model.Reaction, isGuestUser bool) (any, error) {
+	if reaction != "" && !slices.Contains(model.Reactions, reaction) {
+		return nil, errors.New("unknown reaction")
+	}
+	if isGuestUser {
+		err := s.repo.CheckPublicPrivacyPermission(ctx, post_id)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err := s.repo.CheckFriendPrivacyPermission(ctx, userId, post_id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	likers, err := s.repo.GetLikers(ctx, limit, offset, post_id, reaction)
+	if err != nil {
+		return nil, err
+	}
+	return likers, nil
+}
+
+// GetComments returns the top-level comments of a post, newest first, each
+// with a preview of its first replies.
+func (s *NewsfeedService) GetComments(ctx context.Context, limit, offset int, user_id, post_id string) (any, error) {
+	if err := s.checkVisible(ctx, user_id, post_id); err != nil {
+		return nil, err
+	}
+	post, err := s.repo.GetPost(ctx, post_id)
+	if err != nil {
+		return nil, errors.New("this post was not found")
+	}
+	showHidden, err := s.canModerateComments(ctx, user_id, post)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.repo.GetComments(ctx, limit, offset, post_id, user_id, showHidden)
+	if err != nil {
+		return nil, err
+	}
+	// Mentions go before redaction, which drops them with the content
+	if err := s.attachCommentMentions(ctx, *comments); err != nil {
+		return nil, err
+	}
+	redactComments(*comments, user_id, showHidden)
+	if err := s.attachReplyPreviews(ctx, user_id, showHidden, *comments); err != nil {
+		return nil, err
+	}
+	if err := s.overlayCommentLiked(ctx, user_id, *comments); err != nil {
+		return nil, err
+	}
+	return &map[string]any{
+		"post_id": post_id,
+		"data":    comments,
+		"limit":   limit,
+		"offset":  offset,
+	}, nil
+}
+
+// GetReplies pages through the replies of one thread, oldest first
+func (s *NewsfeedService) GetReplies(ctx context.Context, limit, offset int, user_id, post_id, comment_id string) (any, error) {
+	parent, err := s.repo.GetComment(ctx, comment_id)
+	if err != nil || parent.PostId != post_id || parent.ParentId != nil {
+		return nil, errors.New("this comment was not found")
+	}
+	if err := s.checkVisible(ctx, user_id, post_id); err != nil {
+		return nil, err
+	}
+	post, err := s.repo.GetPost(ctx, post_id)
+	if err != nil {
+		return nil, errors.New("this post was not found")
+	}
+	showHidden, err := s.canModerateComments(ctx, user_id, post)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := s.repo.GetReplies(ctx, limit, offset, post_id, comment_id, user_id, showHidden)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachCommentMentions(ctx, *replies); err != nil {
+		return nil, err
+	}
+	redactComments(*replies, user_id, showHidden)
+	if err := s.overlayCommentLiked(ctx, user_id, *replies); err != nil {
+		return nil, err
+	}
+	return &map[string]any{
+		"post_id":    post_id,
+		"comment_id": comment_id,
+		"data":       replies,
+		"limit":      limit,
+		"offset":     offset,
+	}, nil
+}
+
+func (s *NewsfeedService) attachReplyPreviews(ctx context.Context, userId string, showHidden bool, comments []model.CommentInfo) error {
+	parentIds := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		if comment.ReplyCount > 0 {
+			parentIds = append(parentIds, comment.CommentId)
+		}
+	}
+	replies, err := s.repo.GetReplyPreviews(ctx, parentIds, replyPreviewSize, userId, showHidden)
+	if err != nil {
+		return err
+	}
+	if err := s.attachCommentMentions(ctx, replies); err != nil {
+		return err
+	}
+	redactComments(replies, userId, showHidden)
+	if err := s.overlayCommentLiked(ctx, userId, replies); err != nil {
+		return err
+	}
+	byParent := make(map[string][]model.CommentInfo)
+	for _, reply := range replies {
+		byParent[*reply.ParentId] = append(byParent[*reply.ParentId], reply)
+	}
+	for i := range comments {
+		comments[i].Replies = byParent[comments[i].CommentId]
+	}
+	return nil
+}
+
+func (s *NewsfeedService) overlayCommentLiked(ctx context.Context, userId string, comments []model.CommentInfo) error {
+	commentIds := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		commentIds = append(commentIds, comment.CommentId)
+	}
+	liked, err := s.repo.GetLikedIds(ctx, userId, model.LikeComment, commentIds)
+	if err != nil {
+		return err
+	}
+	likedSet := make(map[string]bool, len(liked))
+	for _, commentId := range liked {
+		likedSet[commentId] = true
+	}
+	for i := range comments {
+		comments[i].Liked = likedSet[comments[i].CommentId]
+	}
+	return nil
+}
+
+func (s *NewsfeedService) attachCommentMentions(ctx context.Context, comments []model.CommentInfo) error {
+	commentIds := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		commentIds = append(commentIds, comment.CommentId)
+	}
+	mentions, err := s.repo.GetMentions(ctx, model.MentionComment, commentIds)
+	if err != nil {
+		return err
+	}
+	byComment := make(map[string][]model.Mention)
+	for _, mention := range mentions {
+		byComment[mention.TargetId] = append(byComment[mention.TargetId], mention)
+	}
+	for i := range comments {
+		comments[i].Mentions = byComment[comments[i].CommentId]
+	}
+	return nil
+}
+
+// GetPostRevisions lists the previous versions of a post to whoever can see it
+func (s *NewsfeedService) GetPostRevisions(ctx context.Context, userId, postId string) (any, error) {
+	posts, err := s.repo.GetPostsByIds(ctx, userId, []string{postId})
+	if err != nil {
+		return nil, err
+	}
+	if len(*posts) == 0 {
+		return nil, errors.New("this post was not found")
+	}
+	return s.repo.GetPostRevisions(ctx, postId)
+}
+
+// GetCommentRevisions lists the previous versions of a comment to whoever can
+// read it, see GetComments.
+func (s *NewsfeedService) GetCommentRevisions(ctx context.Context, userId, postId, commentId string) (any, error) {
+	posts, err := s.repo.GetPostsByIds(ctx, userId, []string{postId})
+	if err != nil {
+		return nil, err
+	}
+	comment, err := s.repo.GetComment(ctx, commentId)
+	if len(*posts) == 0 || err != nil || comment.PostId != postId || comment.Status == model.DeletedComment {
+		return nil, errors.New("this comment was not found")
+	}
+	if comment.Status == model.HiddenComment && comment.UserId != userId {
+		post, err := s.repo.GetPost(ctx, postId)
+		if err != nil {
+			return nil, err
+		}
+		allowed, err := s.canModerateComments(ctx, userId, post)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, errors.New("this comment was not found")
+		}
+	}
+	return s.repo.GetCommentRevisions(ctx, commentId)
+}
+
+// PutComment lets the author edit a comment that was not deleted
+func (s *NewsfeedService) PutComment(ctx context.Context, userId, postId string, commentPut *model.CommentPut) (any, error) {
+	comment, err := s.repo.GetComment(ctx, commentPut.CommentId)
+	if err != nil || comment.PostId != postId || comment.Status == model.DeletedComment {
+		return nil, errors.New("this comment was not found")
+	}
+	if comment.UserId != userId {
+		return nil, errors.New("you don't have permission to modify this comment")
+	}
+	filterInput := &FilterInput{UserId: userId, Target: model.ReportedComment, Content: commentPut.Content, Edit: true}
+	verdict, err := s.filterContent(ctx, filterInput)
+	if err != nil {
+		return nil, err
+	}
+	oldMentions, err := s.repo.GetMentions(ctx, model.MentionComment, []string{comment.CommentId})
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := s.resolveMentions(ctx, model.MentionComment, comment.CommentId, commentPut.Content)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	revision := &model.CommentRevision{
+		RevisionId: uuid.NewString(),
+		CommentId:  comment.CommentId,
+		Content:    comment.Content,
+		CreatedAt:  time.Now(),
+	}
+	if err = s.repo.CreateCommentRevision(ctx, tx, revision); err != nil {
+		return nil, err
+	}
+	if err = s.repo.PutComment(ctx, tx, comment.CommentId, commentPut.Content); err != nil {
+		return nil, err
+	}
+	if err = s.repo.SetMentionsTransaction(ctx, tx, model.MentionComment, comment.CommentId, mentions); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.filters.Record(ctx, filterInput)
+	s.filters.Flag(ctx, comment.CommentId, filterInput, verdict)
+	s.notifyMentioned(ctx, userId, postId, &comment.CommentId, mentions, oldMentions)
+	return &map[string]any{
+		"comment_id": commentPut.CommentId,
+		"message":    "modify comment successfully",
+	}, nil
+}
+
+// DeleteComment soft deletes a comment, for its author or a moderator.
+// Deleted comments with replies are listed as placeholders.
+func (s *NewsfeedService) DeleteComment(ctx context.Context, userId, postId, commentId string) error {
+	comment, err := s.repo.GetComment(ctx, commentId)
+	if err != nil || comment.PostId != postId || comment.Status == model.DeletedComment {
+		return errors.New("this comment was not found")
+	}
+	if comment.UserId != userId {
+		role, err := s.repo.GetRole(ctx, userId)
+		if err != nil {
+			return err
+		}
+		if role != model.RoleModerator {
+			return errors.New("you don't have permission to delete this comment")
+		}
+	}
+	return s.setCommentStatus(ctx, comment, model.DeletedComment, nil)
+}
+
+// SetCommentHidden hides or unhides a comment, for the owner of the post or a
+// moderator. Hidden comments stay visible to their author.
+func (s *NewsfeedService) SetCommentHidden(ctx context.Context, userId, postId, commentId string, hidden bool) error {
+	comment, err := s.repo.GetComment(ctx, commentId)
+	if err != nil || comment.PostId != postId || comment.Status == model.DeletedComment {
+		return errors.New("this comment was not found")
+	}
+	post, err := s.repo.GetPost(ctx, postId)
+	if err != nil {
+		return errors.New("this post was not found")
+	}
+	allowed, err := s.canModerateComments(ctx, userId, post)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("you don't have permission to hide this comment")
+	}
+	status := model.ActiveComment
+	if hidden {
+		status = model.HiddenComment
+	}
+	if comment.Status == status {
+		return nil
+	}
+	return s.setCommentStatus(ctx, comment, status, nil)
+}
+
+// setCommentStatus moves a comment to a new status. The commentCount of the
+// post and the repliesCount of the thread only count active comments. audit,
+// when given, writes to the same transaction.
+func (s *NewsfeedService) setCommentStatus(ctx context.Context, comment *model.Comment, status model.CommentStatus, audit func(tx *bun.Tx) error) error {
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = s.repo.SetCommentStatus(ctx, tx, comment.CommentId, status); err != nil {
+		return err
+	}
+	if comment.Status == model.ActiveComment {
+		if err = s.repo.DecreaseCommentCount(ctx, tx, comment.PostId); err != nil {
+			return err
+		}
+		if comment.ParentId != nil {
+			err = s.repo.DecreaseReplyCount(ctx, tx, *comment.ParentId)
+		}
+	} else if status == model.ActiveComment {
+		if err = s.repo.IncreaseCommentCount(ctx, tx, comment.PostId); err != nil {
+			return err
+		}
+		if comment.ParentId != nil {
+			err = s.repo.IncreaseReplyCount(ctx, tx, *comment.ParentId)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if audit != nil {
+		if err = audit(tx); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	logTimelineError("cache invalidation", s.feedCache.DeleteCachedPost(ctx, comment.PostId))
+	return nil
+}
+
+// canModerateComments tells whether userId may see and hide every comment of
+// post: its owner and moderators can.
+func (s *NewsfeedService) canModerateComments(ctx context.Context, userId string, post *model.Post) (bool, error) {
+	if post.UserId == userId {
+		return true, nil
+	}
+	if userId == "guest" {
+		return false, nil
+	}
+	role, err := s.repo.GetRole(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	return role == model.RoleModerator, nil
+}
+
+// redactComments turns the comments the viewer may not read, the deleted ones
+// and the hidden ones of other users, into placeholders keeping their thread.
+// Top-level comments, replies and reply previews all go through it.
+func redactComments(comments []model.CommentInfo, viewerId string, showHidden bool) {
+	for i, comment := range comments {
+		if comment.Status == model.ActiveComment ||
+			comment.Status == model.HiddenComment && (showHidden || comment.UserId == viewerId) {
+			continue
+		}
+		content := "comment deleted"
+		if comment.Status == model.HiddenComment {
+			content = "comment hidden"
+		}
+		comments[i] = model.CommentInfo{
+			CommentId:  comment.CommentId,
+			ParentId:   comment.ParentId,
+			Content:    content,
+			ReplyCount: comment.ReplyCount,
+			Status:     comment.Status,
+			CreatedAt:  comment.CreatedAt,
+		}
+	}
 }
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"program/internal/model"
+	notificationRepo "program/internal/repositories/notification"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type INotificationService interface {
+	Notify(ctx context.Context, notifications ...model.Notification)
+	GetNotifications(ctx context.Context, limit, offset int, userId string) (any, error)
+	MarkRead(ctx context.Context, userId string, notificationIds []string) (any, error)
+}
+
+type NotificationService struct {
+	repo notificationRepo.INotificationRepo
+}
+
+func NewNotificationService(repo notificationRepo.INotificationRepo) INotificationService {
+	return &NotificationService{
+		repo: repo,
+	}
+}
+
+// Notify stores notifications on behalf of another action. A failure is
+// logged but never fails the action itself.
+func (s *NotificationService) Notify(ctx context.Context, notifications ...model.Notification) {
+	for i := range notifications {
+		notifications[i].NotificationId = uuid.NewString()
+		notifications[i].CreatedAt = time.Now()
+	}
+	if err := s.repo.CreateNotifications(ctx, notifications); err != nil {
+		log.WithError(err).Warn("can not store notifications")
+	}
+}
+
+func (s *NotificationService) GetNotifications(ctx context.Context, limit, offset int, userId string) (any, error) {
+	notifications, err := s.repo.GetNotifications(ctx, limit, offset, userId)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.repo.CountUnread(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &map[string]any{
+		"data":   notifications,
+		"unread": unread,
+		"limit":  limit,
+		"offset": offset,
+	}, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userId string, notificationIds []string) (any, error) {
+	if err := s.repo.MarkRead(ctx, userId, notificationIds); err != nil {
+		return nil, err
+	}
+	return &map[string]any{
+		"status":  "successful",
+		"message": "notifications marked as read",
+	}, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"program/internal/model"
+)
+
+// Number of posts a user can pin on their timeline
+const maxPinnedPosts = 3
+
+// SetPostPinned pins one of the user's own posts on top of their timeline,
+// or unpins it. Private posts can not be pinned since nobody else would see
+// them.
+func (s *NewsfeedService) SetPostPinned(ctx context.Context, userId, postId string, pinned bool) error {
+	post, err := s.repo.GetPost(ctx, postId)
+	if err != nil || post.Status != model.PublishedPost {
+		return errors.New("this post was not found")
+	}
+	if post.UserId != userId {
+		return errors.New("you don't have permission to pin this post")
+	}
+	if pinned && post.Privacy == model.Private {
+		return errors.New("private posts can not be pinned")
+	}
+	if (post.PinnedAt != nil) == pinned {
+		return nil
+	}
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if pinned {
+		var count int
+		if count, err = s.repo.CountPinnedPostsTransaction(ctx, tx, userId); err != nil {
+			return err
+		}
+		if count >= maxPinnedPosts {
+			err = fmt.Errorf("you can pin at most %d posts", maxPinnedPosts)
+			return err
+		}
+	}
+	if err = s.repo.SetPinnedTransaction(ctx, tx, postId, pinned); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	s.invalidatePost(ctx, post)
+	return nil
+}
+
+// GetUserPosts returns the timeline of one author as seen by the viewer, with
+// the author's pinned posts first.
+func (s *NewsfeedService) GetUserPosts(ctx context.Context, limit, offset int, userId, authorId string) (any, error) {
+	postIds, err := s.repo.GetUserPostIds(ctx, userId, authorId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPostsByIds(ctx, userId, postIds)
+}
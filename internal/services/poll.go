@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"program/internal/model"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newPoll builds the poll of a new post. A poll must stay open for a while
+// after the post is published. Drafts have no publish time yet, they are
+// checked again by checkPollOpen when they are published.
+func newPoll(post *model.Post, pollPost *model.PollPost) (*model.Poll, []model.PollOption, error) {
+	opensAt := post.CreatedAt
+	if post.PublishAt != nil {
+		opensAt = *post.PublishAt
+	}
+	if !pollPost.ClosesAt.After(opensAt) {
+		return nil, nil, errors.New("a poll must close after it is published")
+	}
+	poll := &model.Poll{
+		PostId:         post.PostId,
+		MultipleChoice: pollPost.MultipleChoice,
+		ClosesAt:       pollPost.ClosesAt,
+		CreatedAt:      post.CreatedAt,
+	}
+	options := make([]model.PollOption, 0, len(pollPost.Options))
+	seen := make(map[string]bool, len(pollPost.Options))
+	for i, text := range pollPost.Options {
+		text = strings.TrimSpace(text)
+		key := strings.ToLower(text)
+		if text == "" || seen[key] {
+			return nil, nil, errors.New("poll options must be distinct and not empty")
+		}
+		seen[key] = true
+		options = append(options, model.PollOption{
+			OptionId: uuid.NewString(),
+			PostId:   post.PostId,
+			Position: i,
+			Text:     text,
+		})
+	}
+	return poll, options, nil
+}
+
+// errPollClosed is returned when a poll would be published after it closes
+var errPollClosed = errors.New("the poll closes before the post is published")
+
+// checkPollOpen refuses to publish or schedule a poll that would already be
+// closed
+func (s *NewsfeedService) checkPollOpen(ctx context.Context, postId string, publishedAt time.Time) error {
+	polls, err := s.repo.GetPolls(ctx, []string{postId})
+	if err != nil {
+		return err
+	}
+	for _, poll := range polls {
+		if !poll.ClosesAt.After(publishedAt) {
+			return errPollClosed
+		}
+	}
+	return nil
+}
+
+// VotePoll records the choice of a viewer in a poll they can see. Votes are
+// final, and are checked and counted under a lock on the poll.
+func (s *NewsfeedService) VotePoll(ctx context.Context, userId, postId string, vote *model.PollVotePost) (any, error) {
+	posts, err := s.repo.GetPostsByIds(ctx, userId, []string{postId})
+	if err != nil {
+		return nil, err
+	}
+	if len(*posts) == 0 {
+		return nil, errors.New("this post was not found")
+	}
+	if (*posts)[0].Type != model.PollPostType {
+		return nil, errors.New("this post is not a poll")
+	}
+	options, err := s.repo.GetPollOptions(ctx, []string{postId})
+	if err != nil {
+		return nil, err
+	}
+	optionIds := make([]string, 0, len(vote.OptionIds))
+	for _, optionId := range vote.OptionIds {
+		if slices.Contains(optionIds, optionId) {
+			continue
+		}
+		if !slices.ContainsFunc(options, func(option model.PollOption) bool { return option.OptionId == optionId }) {
+			return nil, errors.New("unknown poll option")
+		}
+		optionIds = append(optionIds, optionId)
+	}
+
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	poll, err := s.repo.LockPollTransaction(ctx, tx, postId)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(poll.ClosesAt) {
+		err = errors.New("this poll is closed")
+		return nil, err
+	}
+	if !poll.MultipleChoice && len(optionIds) > 1 {
+		err = errors.New("this poll accepts a single choice")
+		return nil, err
+	}
+	voted, err := s.repo.HasVotedTransaction(ctx, tx, postId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if voted {
+		err = errors.New("you have already voted in this poll")
+		return nil, err
+	}
+	votes := make([]model.PollVote, 0, len(optionIds))
+	for _, optionId := range optionIds {
+		votes = append(votes, model.PollVote{
+			VoteId:    uuid.NewString(),
+			PostId:    postId,
+			OptionId:  optionId,
+			UserId:    userId,
+			CreatedAt: time.Now(),
+		})
+	}
+	if err = s.repo.CreatePollVotesTransaction(ctx, tx, votes); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	states, err := s.getPollStates(ctx, userId, *posts)
+	if err != nil {
+		return nil, err
+	}
+	return states[postId], nil
+}
+
+// getPollStates builds the poll of each poll post for the viewer. Results are
+// only revealed to viewers who voted, or once the poll is closed.
+func (s *NewsfeedService) getPollStates(ctx context.Context, userId string, newsfeed []model.NewsFeed) (map[string]*model.PollState, error) {
+	states := make(map[string]*model.PollState)
+	postIds := make([]string, 0)
+	for _, post := range newsfeed {
+		if post.Type == model.PollPostType {
+			postIds = append(postIds, post.PostId)
+		}
+	}
+	if len(postIds) == 0 {
+		return states, nil
+	}
+	polls, err := s.repo.GetPolls(ctx, postIds)
+	if err != nil {
+		return nil, err
+	}
+	options, err := s.repo.GetPollOptions(ctx, postIds)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := s.repo.GetPollVotes(ctx, userId, postIds)
+	if err != nil {
+		return nil, err
+	}
+	chosen := make(map[string]bool, len(votes))
+	for _, vote := range votes {
+		chosen[vote.OptionId] = true
+	}
+	for _, poll := range polls {
+		states[poll.PostId] = &model.PollState{
+			MultipleChoice: poll.MultipleChoice,
+			ClosesAt:       poll.ClosesAt,
+			Closed:         !time.Now().Before(poll.ClosesAt),
+			Options:        make([]model.PollOptionState, 0),
+		}
+	}
+	for _, option := range options {
+		if state, ok := states[option.PostId]; ok {
+			state.Options = append(state.Options, model.PollOptionState{
+				OptionId: option.OptionId,
+				Text:     option.Text,
+				Chosen:   chosen[option.OptionId],
+			})
+			state.Voted = state.Voted || chosen[option.OptionId]
+		}
+	}
+	for _, option := range options {
+		state, ok := states[option.PostId]
+		if !ok || !(state.Voted || state.Closed) {
+			continue
+		}
+		if state.TotalVotes == nil {
+			state.TotalVotes = new(int)
+		}
+		*state.TotalVotes += option.VoteCount
+		for i := range state.Options {
+			if state.Options[i].OptionId == option.OptionId {
+				count := option.VoteCount
+				state.Options[i].VoteCount = &count
+			}
+		}
+	}
+	return states, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"hash/fnv"
+	"math"
+	"program/internal/model"
+	"sort"
+	"time"
+)
+
+// RankingSignals holds the per-viewer inputs of a ranking pass, keyed by
+// author user id.
+type RankingSignals struct {
+	Affinity map[string]int
+	Mutual   map[string]bool
+}
+
+// IFeedRanker orders a pool of candidate posts for one viewer.
+type IFeedRanker interface {
+	Name() string
+	Rank(viewerId string, posts []model.NewsFeed, signals *RankingSignals, now time.Time) []model.NewsFeed
+}
+
+type RankingWeights struct {
+	HalfLifeHours float64
+	Likes         float64
+	Comments      float64
+	Shares        float64
+	Affinity      float64
+	Mutual        float64
+}
+
+var DefaultRankingWeights = RankingWeights{
+	HalfLifeHours: 12,
+	Likes:         1,
+	Comments:      2,
+	Shares:        3,
+	Affinity:      1.5,
+	Mutual:        1,
+}
+
+type RankingConfig struct {
+	CandidatePool int
+	AffinityDays  int
+}
+
+// WeightedRanker scores a post by its engagement and the viewer's relation
+// to its author, decayed by the post age:
+//
+//	(1 + Σ weight·log(1+signal) + mutual) · 0.5^(age/halfLife)
+type WeightedRanker struct {
+	name    string
+	weights RankingWeights
+}
+
+func NewWeightedRanker(name string, weights RankingWeights) IFeedRanker {
+	return &WeightedRanker{
+		name:    name,
+		weights: weights,
+	}
+}
+
+func (r *WeightedRanker) Name() string {
+	return r.name
+}
+
+func (r *WeightedRanker) Rank(viewerId string, posts []model.NewsFeed, signals *RankingSignals, now time.Time) []model.NewsFeed {
+	scores := make(map[string]float64, len(posts))
+	for _, post := range posts {
+		scores[post.PostId] = r.score(post, signals, now)
+	}
+	ranked := append([]model.NewsFeed(nil), posts...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i].PostId] > scores[ranked[j].PostId]
+	})
+	return ranked
+}
+
+func (r *WeightedRanker) score(post model.NewsFeed, signals *RankingSignals, now time.Time) float64 {
+	w := r.weights
+	score := 1 +
+		w.Likes*math.Log1p(float64(post.LikeCount)) +
+		w.Comments*math.Log1p(float64(post.CommentCount)) +
+		w.Shares*math.Log1p(float64(post.ShareCount)) +
+		w.Affinity*math.Log1p(float64(signals.Affinity[post.UserId]))
+	if signals.Mutual[post.UserId] {
+		score += w.Mutual
+	}
+	if w.HalfLifeHours > 0 {
+		ageHours := max(now.Sub(post.CreatedAt).Hours(), 0)
+		score *= math.Pow(0.5, ageHours/w.HalfLifeHours)
+	}
+	return score
+}
+
+// ExperimentRanker splits viewers into stable buckets, one per variant, so
+// that ranking strategies can be A/B tested.
+type ExperimentRanker struct {
+	variants []IFeedRanker
+}
+
+func NewExperimentRanker(variants ...IFeedRanker) IFeedRanker {
+	return &ExperimentRanker{
+		variants: variants,
+	}
+}
+
+func (r *ExperimentRanker) Name() string {
+	return "experiment"
+}
+
+func (r *ExperimentRanker) Variant(viewerId string) IFeedRanker {
+	h := fnv.New32a()
+	h.Write([]byte(viewerId))
+	return r.variants[h.Sum32()%uint32(len(r.variants))]
+}
+
+func (r *ExperimentRanker) Rank(viewerId string, posts []model.NewsFeed, signals *RankingSignals, now time.Time) []model.NewsFeed {
+	return r.Variant(viewerId).Rank(viewerId, posts, signals, now)
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"program/internal/model"
+	newsfeedRepo "program/internal/repositories/newfeed"
+)
+
+type IReconcileService interface {
+	Reconcile(ctx context.Context, fix bool) ([]model.CounterDrift, error)
+}
+
+// ReconcileService recomputes the denormalized like, comment, share, reply and
+// poll vote counters from their source tables.
+type ReconcileService struct {
+	repo newsfeedRepo.INewsfeedRepo
+}
+
+func NewReconcileService(repo newsfeedRepo.INewsfeedRepo) IReconcileService {
+	return &ReconcileService{
+		repo: repo,
+	}
+}
+
+// Reconcile reports every drifted counter and, with fix, resets them to their
+// actual values in one transaction. Cached post payloads catch up on expiry.
+func (s *ReconcileService) Reconcile(ctx context.Context, fix bool) ([]model.CounterDrift, error) {
+	drift, err := s.repo.FindCounterDrift(ctx)
+	if err != nil || !fix || len(drift) == 0 {
+		return drift, err
+	}
+	tx, err := s.repo.GetDBTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = s.repo.FixCounterDrift(ctx, tx, drift); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return drift, nil
+}
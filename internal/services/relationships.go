@@ -14,15 +14,23 @@ type IRelationshipsService interface {
 	GetFollowing(ctx context.Context, limit, offset int, userId string) (any, error)
 	GetFollowRelationshipCount(ctx context.Context, userId string) (any, error)
 	ToggleFollow(ctx context.Context, followerId, followingId string) (any, error)
+	CreateAudienceList(ctx context.Context, userId string, request *model.AudienceListPost) (any, error)
+	GetAudienceLists(ctx context.Context, userId string) (any, error)
+	DeleteAudienceList(ctx context.Context, userId, listId string) error
+	GetAudienceMembers(ctx context.Context, limit, offset int, userId, listId string) (any, error)
+	AddAudienceMembers(ctx context.Context, userId, listId string, request *model.AudienceMembersPost) error
+	RemoveAudienceMembers(ctx context.Context, userId, listId string, request *model.AudienceMembersPost) error
 }
 
 type RelationshipsService struct {
-	repo relationshipsRepo.IRelationshipsRepo
+	repo     relationshipsRepo.IRelationshipsRepo
+	timeline ITimelineService
 }
 
-func NewRelationshipsService(repo relationshipsRepo.IRelationshipsRepo) IRelationshipsService {
+func NewRelationshipsService(repo relationshipsRepo.IRelationshipsRepo, timeline ITimelineService) IRelationshipsService {
 	return &RelationshipsService{
-		repo: repo,
+		repo:     repo,
+		timeline: timeline,
 	}
 }
 
@@ -106,6 +114,13 @@ func (s *RelationshipsService) ToggleFollow(ctx context.Context, followerId, fol
 	if err != nil {
 		return nil, err
 	}
+	if exists && isActive {
+		logTimelineError("eviction", s.timeline.Evict(ctx, followerId, followingId))
+	} else {
+		logTimelineError("backfill", s.timeline.Backfill(ctx, followerId, followingId))
+	}
+	// The mutual status may have changed too, which affects what both sides see
+	logTimelineError("cache invalidation", s.timeline.InvalidateFeeds(ctx, followerId, followingId))
 	return &map[string]any{
 		"status":   "successful",
 		"message":  message,
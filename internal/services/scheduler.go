@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RunPostScheduler publishes the scheduled posts that became due every
+// interval, until ctx is cancelled.
+func RunPostScheduler(ctx context.Context, service INewsfeedService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			published, err := service.PublishDuePosts(ctx)
+			if err != nil {
+				log.WithError(err).Warn("publishing scheduled posts failed")
+				continue
+			}
+			if published > 0 {
+				log.WithField("count", published).Info("published scheduled posts")
+			}
+		}
+	}
+}
+
+// RunSnoozeSweeper ends the snoozes that ran out every interval, until ctx is
+// cancelled.
+func RunSnoozeSweeper(ctx context.Context, service IFeedPreferenceService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ended, err := service.EndExpiredSnoozes(ctx)
+			if err != nil {
+				log.WithError(err).Warn("ending expired snoozes failed")
+				continue
+			}
+			if ended > 0 {
+				log.WithField("count", ended).Info("ended expired snoozes")
+			}
+		}
+	}
+}
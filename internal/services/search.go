@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"html"
+	"program/internal/model"
+	searchRepo "program/internal/repositories/search"
+	"program/internal/textnorm"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Length in runes of the text kept around the first match of a highlight
+const snippetLength = 160
+
+type ISearchService interface {
+	Search(ctx context.Context, userId, query string, searchType model.SearchType, limit int, cursor string) (any, error)
+}
+
+type SearchService struct {
+	repo     searchRepo.ISearchRepo
+	newsfeed INewsfeedService
+}
+
+func NewSearchService(repo searchRepo.ISearchRepo, newsfeed INewsfeedService) ISearchService {
+	return &SearchService{
+		repo:     repo,
+		newsfeed: newsfeed,
+	}
+}
+
+// Search runs query against posts or people. The cursor is opaque to clients,
+// it encodes the position of the last result and is empty on the last page.
+func (s *SearchService) Search(ctx context.Context, userId, query string, searchType model.SearchType, limit int, cursor string) (any, error) {
+	terms := textnorm.Tokenize(query)
+	if len(terms) == 0 {
+		return nil, errors.New("search query can not be empty")
+	}
+	after, err := decodeSearchCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.SearchPage{}
+	switch searchType {
+	case model.SearchPosts:
+		hits, err := s.repo.SearchPosts(ctx, userId, query, limit, after)
+		if err != nil {
+			return nil, err
+		}
+		postIds := make([]string, 0, len(hits))
+		for _, hit := range hits {
+			postIds = append(postIds, hit.Id)
+		}
+		posts, err := s.newsfeed.GetPostsByIds(ctx, userId, postIds)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]model.PostResult, 0, len(*posts))
+		for _, post := range *posts {
+			results = append(results, model.PostResult{
+				NewsFeed:  post,
+				Highlight: highlight(post.Content, terms),
+			})
+		}
+		page.Results = results
+		page.NextCursor = nextSearchCursor(hits, limit)
+	case model.SearchPeople:
+		people, err := s.repo.SearchPeople(ctx, userId, query, limit, after)
+		if err != nil {
+			return nil, err
+		}
+		hits := make([]model.SearchKey, 0, len(people))
+		for i := range people {
+			hits = append(hits, people[i].Key())
+			people[i].Highlight = highlight(people[i].FirstName+" "+people[i].LastName+" @"+people[i].Username, terms)
+		}
+		page.Results = people
+		page.NextCursor = nextSearchCursor(hits, limit)
+	default:
+		return nil, errors.New("search type must be posts or people")
+	}
+	return page, nil
+}
+
+// A search cursor is the key of the last result of a page: its score, its
+// creation time and its id
+func decodeSearchCursor(cursor string) (*model.SearchKey, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return nil, ErrInvalidCursor
+	}
+	score, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	unixNano, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &model.SearchKey{Score: score, CreatedAt: time.Unix(0, unixNano), Id: parts[2]}, nil
+}
+
+// nextSearchCursor assumes more results when the page came back full
+func nextSearchCursor(hits []model.SearchKey, limit int) string {
+	if limit <= 0 || len(hits) < limit {
+		return ""
+	}
+	last := hits[len(hits)-1]
+	raw := strconv.FormatFloat(last.Score, 'g', -1, 64) + ":" + strconv.FormatInt(last.CreatedAt.UnixNano(), 10) + ":" + last.Id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// highlight returns an HTML-escaped snippet of text around its first match,
+// with every word matching one of terms wrapped in <em>. Words are compared
+// folded, and the last term also matches as a prefix like the search does.
+func highlight(text string, terms []string) string {
+	words := textnorm.WordIndexes(text)
+	matched := make([]bool, len(words))
+	first := -1
+	for i, word := range words {
+		folded := textnorm.Fold(text[word[0]:word[1]])
+		for j, term := range terms {
+			if folded == term || (j == len(terms)-1 && strings.HasPrefix(folded, term)) {
+				matched[i] = true
+				break
+			}
+		}
+		if matched[i] && first < 0 {
+			first = i
+		}
+	}
+
+	start, end := 0, len(text)
+	if first >= 0 {
+		start = snippetStart(text, words[first][0])
+	}
+	if runes := []rune(text[start:]); len(runes) > snippetLength {
+		end = start + len(string(runes[:snippetLength]))
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for i, word := range words {
+		if !matched[i] || word[0] < start || word[1] > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:word[0]]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(text[word[0]:word[1]]))
+		b.WriteString("</em>")
+		pos = word[1]
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// snippetStart moves back from the first match so that it is shown with some
+// leading context, stopping at a word boundary.
+func snippetStart(text string, match int) int {
+	runes := []rune(text[:match])
+	if len(runes) <= snippetLength/4 {
+		return 0
+	}
+	start := len(string(runes[:len(runes)-snippetLength/4]))
+	if space := strings.IndexByte(text[start:match], ' '); space >= 0 {
+		return start + space + 1
+	}
+	return start
+}
+
+// logSearchError reports an index maintenance failure without failing the
+// request that triggered it.
+func logSearchError(action string, err error) {
+	if err != nil {
+		log.WithError(err).Warn("search " + action + " failed")
+	}
+}
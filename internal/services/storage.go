@@ -0,0 +1,33 @@
+package services
+
+import (
+	"mime/multipart"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IFileStorage saves uploaded files and returns the path they are served from
+type IFileStorage interface {
+	Save(ctx *gin.Context, fileUploaded *multipart.FileHeader, dir, filename string) (string, error)
+}
+
+// LocalFileStorage keeps uploads on the local disk under Root, which the http
+// server exposes as a static directory.
+type LocalFileStorage struct {
+	Root string
+}
+
+func (s *LocalFileStorage) Save(ctx *gin.Context, fileUploaded *multipart.FileHeader, dir, filename string) (string, error) {
+	uploadPath := filepath.Join(s.Root, dir)
+	if _, err := os.Stat(uploadPath); os.IsNotExist(err) {
+		os.MkdirAll(uploadPath, os.ModePerm)
+	}
+
+	fullfilename := filepath.Join(uploadPath, filename)
+	if err := ctx.SaveUploadedFile(fileUploaded, fullfilename); err != nil {
+		return "", err
+	}
+	return fullfilename, nil
+}
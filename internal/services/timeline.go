@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"program/internal/model"
+	newsfeedRepo "program/internal/repositories/newfeed"
+	timelineRepo "program/internal/repositories/timeline"
+	"slices"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type ITimelineService interface {
+	FanOutPost(ctx context.Context, post *model.Post) error
+	GetTimelinePage(ctx context.Context, userId string, limit, offset int) ([]model.TimelineEntry, error)
+	Backfill(ctx context.Context, followerId, followingId string) error
+	Evict(ctx context.Context, followerId, followingId string) error
+	InvalidateFeeds(ctx context.Context, userIds ...string) error
+	InvalidateAudience(ctx context.Context, authorId string) error
+}
+
+// TimelineConfig controls the Redis fan-out-on-write timelines.
+// Accounts with at least CelebrityThreshold followers are not fanned out,
+// their posts are merged into each reader's timeline at read time instead.
+// Timelines are seeded with the last WindowDays of posts, the older ones are
+// paged from MySQL by GetNewsfeed.
+type TimelineConfig struct {
+	MaxLength          int
+	CelebrityThreshold int
+	WindowDays         int
+}
+
+type TimelineService struct {
+	config    TimelineConfig
+	repo      newsfeedRepo.INewsfeedRepo
+	timelines timelineRepo.ITimelineRepo
+}
+
+func NewTimelineService(config TimelineConfig, repo newsfeedRepo.INewsfeedRepo, timelines timelineRepo.ITimelineRepo) ITimelineService {
+	return &TimelineService{
+		config:    config,
+		repo:      repo,
+		timelines: timelines,
+	}
+}
+
+func (s *TimelineService) window() time.Time {
+	return time.Now().AddDate(0, 0, -s.config.WindowDays)
+}
+
+func (s *TimelineService) isCelebrity(ctx context.Context, userId string) (bool, error) {
+	if s.config.CelebrityThreshold <= 0 {
+		return false, nil
+	}
+	followers, err := s.repo.CountFollowers(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	return followers >= s.config.CelebrityThreshold, nil
+}
+
+// FanOutPost pushes a new post into the author's timeline and, unless the
+// author is a celebrity, into the timeline of every follower allowed to see it
+// who is not snoozing the author.
+func (s *TimelineService) FanOutPost(ctx context.Context, post *model.Post) error {
+	entry := []model.TimelineEntry{{PostId: post.PostId, CreatedAt: post.CreatedAt}}
+	targets := []string{post.UserId}
+
+	celebrity, err := s.isCelebrity(ctx, post.UserId)
+	if err != nil {
+		return err
+	}
+	if !celebrity {
+		followers, err := s.repo.GetFanOutTargets(ctx, post.UserId, post.PostId, post.Privacy)
+		if err != nil {
+			return err
+		}
+		snoozers, err := s.repo.GetSnoozerIds(ctx, post.UserId, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, followerId := range followers {
+			if !slices.Contains(snoozers, followerId) {
+				targets = append(targets, followerId)
+			}
+		}
+	}
+	return s.timelines.PushToTimelines(ctx, targets, entry, s.config.MaxLength)
+}
+
+// GetTimelinePage returns one page of post references for userId, newest
+// first. The stored timeline is rebuilt from MySQL when missing and merged
+// with the recent posts of followed celebrities.
+func (s *TimelineService) GetTimelinePage(ctx context.Context, userId string, limit, offset int) ([]model.TimelineEntry, error) {
+	existed, err := s.timelines.TimelineExists(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !existed {
+		seed, err := s.repo.GetTimelineSeed(ctx, userId, s.config.WindowDays, s.config.MaxLength)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.timelines.PushToTimelines(ctx, []string{userId}, seed, s.config.MaxLength); err != nil {
+			return nil, err
+		}
+	}
+
+	end := offset + limit
+	if limit <= 0 {
+		end = offset + s.config.MaxLength
+	}
+	entries, err := s.timelines.GetTimeline(ctx, userId, 0, int64(end-1))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.CelebrityThreshold > 0 {
+		celebrities, err := s.repo.GetFollowedCelebrities(ctx, userId, s.config.CelebrityThreshold)
+		if err != nil {
+			return nil, err
+		}
+		if len(celebrities) > 0 {
+			pulled, err := s.repo.GetRecentPostRefs(ctx, userId, celebrities, s.window(), end)
+			if err != nil {
+				return nil, err
+			}
+			entries = mergeTimelineEntries(entries, pulled)
+		}
+	}
+
+	if offset >= len(entries) {
+		return []model.TimelineEntry{}, nil
+	}
+	return entries[offset:min(end, len(entries))], nil
+}
+
+// Backfill copies the recent posts of a newly followed account into the
+// follower's timeline. Celebrities are skipped since they are read on demand,
+// and a missing timeline will be seeded on the next read anyway.
+func (s *TimelineService) Backfill(ctx context.Context, followerId, followingId string) error {
+	existed, err := s.timelines.TimelineExists(ctx, followerId)
+	if err != nil || !existed {
+		return err
+	}
+	celebrity, err := s.isCelebrity(ctx, followingId)
+	if err != nil || celebrity {
+		return err
+	}
+	entries, err := s.repo.GetRecentPostRefs(ctx, followerId, []string{followingId}, s.window(), s.config.MaxLength)
+	if err != nil {
+		return err
+	}
+	return s.timelines.PushToTimelines(ctx, []string{followerId}, entries, s.config.MaxLength)
+}
+
+// Evict removes an unfollowed account's posts from the follower's timeline.
+// Only posts newer than the oldest timeline entry can still be in it.
+func (s *TimelineService) Evict(ctx context.Context, followerId, followingId string) error {
+	oldest, err := s.timelines.GetOldestEntry(ctx, followerId)
+	if err != nil || oldest == nil {
+		return err
+	}
+	postIds, err := s.repo.GetAuthorPostIdsSince(ctx, followingId, oldest.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return s.timelines.RemoveFromTimeline(ctx, followerId, postIds)
+}
+
+// InvalidateFeeds drops the cached newsfeed pages of the given users.
+func (s *TimelineService) InvalidateFeeds(ctx context.Context, userIds ...string) error {
+	return s.timelines.InvalidateFeedPages(ctx, userIds)
+}
+
+// InvalidateAudience drops the cached newsfeed pages of an author and of
+// every active follower, whatever the privacy of the post that changed.
+func (s *TimelineService) InvalidateAudience(ctx context.Context, authorId string) error {
+	followers, err := s.repo.GetFanOutTargets(ctx, authorId, "", model.Public)
+	if err != nil {
+		return err
+	}
+	return s.timelines.InvalidateFeedPages(ctx, append(followers, authorId))
+}
+
+// mergeTimelineEntries merges two timelines newest first, dropping duplicates.
+func mergeTimelineEntries(a, b []model.TimelineEntry) []model.TimelineEntry {
+	seen := make(map[string]bool, len(a)+len(b))
+	merged := make([]model.TimelineEntry, 0, len(a)+len(b))
+	for _, entries := range [][]model.TimelineEntry{a, b} {
+		for _, entry := range entries {
+			if seen[entry.PostId] {
+				continue
+			}
+			seen[entry.PostId] = true
+			merged = append(merged, entry)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.After(merged[j].CreatedAt)
+	})
+	return merged
+}
+
+// logTimelineError reports a timeline maintenance failure without failing the
+// request that triggered it.
+func logTimelineError(action string, err error) {
+	if err != nil {
+		log.WithError(err).Warn("timeline " + action + " failed")
+	}
+}
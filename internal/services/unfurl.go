@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"program/internal/model"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// A link runs from its scheme to the next whitespace or markup character
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// ExtractLink returns the first http(s) link of a post content, without the
+// punctuation that usually follows a link in a sentence.
+func ExtractLink(content string) string {
+	link := linkPattern.FindString(content)
+	return strings.TrimRight(link, ".,;:!?)]}")
+}
+
+type IUnfurler interface {
+	Unfurl(ctx context.Context, link string) (*model.LinkPreview, error)
+}
+
+// UnfurlConfig bounds the fetch of a linked page. AllowPrivateNetworks turns
+// the SSRF protection off and is only meant for tests against a local server.
+type UnfurlConfig struct {
+	Timeout              time.Duration
+	MaxBodyBytes         int64
+	MaxRedirects         int
+	UserAgent            string
+	AllowPrivateNetworks bool
+}
+
+// OpenGraphUnfurler reads the OpenGraph tags of a page, falling back to its
+// title and description meta tag.
+type OpenGraphUnfurler struct {
+	config UnfurlConfig
+	client *http.Client
+}
+
+var errPrivateAddress = errors.New("link points to a private address")
+
+// Ranges that are not covered by the net.IP helpers but must not be reached
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func NewOpenGraphUnfurler(config UnfurlConfig) IUnfurler {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateNetworks {
+		// Checked on the resolved address of every connection, redirects
+		// included, so DNS tricks can not reach internal services
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+	transport := &http.Transport{
+		Proxy:                  nil,
+		DialContext:            dialer.DialContext,
+		TLSHandshakeTimeout:    config.Timeout,
+		ResponseHeaderTimeout:  config.Timeout,
+		MaxResponseHeaderBytes: 64 << 10,
+		DisableKeepAlives:      true,
+	}
+	client := &http.Client{
+		Timeout:   config.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > config.MaxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return errors.New("unsupported redirect scheme")
+			}
+			return nil
+		},
+	}
+	return &OpenGraphUnfurler{
+		config: config,
+		client: client,
+	}
+}
+
+func (u *OpenGraphUnfurler) Unfurl(ctx context.Context, link string) (*model.LinkPreview, error) {
+	target, err := url.Parse(link)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, errors.New("invalid link")
+	}
+	ctx, cancel := context.WithTimeout(ctx, u.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	if u.config.UserAgent != "" {
+		req.Header.Set("User-Agent", u.config.UserAgent)
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("link answered with status %d", resp.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" {
+		return nil, errors.New("link is not an html page")
+	}
+
+	preview := parseOpenGraph(io.LimitReader(resp.Body, u.config.MaxBodyBytes), resp.Request.URL)
+	preview.Url = link
+	preview.FetchedAt = time.Now()
+	if preview.SiteName == "" {
+		preview.SiteName = resp.Request.URL.Hostname()
+	}
+	return preview, nil
+}
+
+// parseOpenGraph reads the head of a page. Relative image links are resolved
+// against base, the final URL after redirects.
+func parseOpenGraph(body io.Reader, base *url.URL) *model.LinkPreview {
+	preview := new(model.LinkPreview)
+	var title, description string
+	tokenizer := html.NewTokenizer(body)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return finishPreview(preview, title, description)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "body":
+				return finishPreview(preview, title, description)
+			case "title":
+				if title == "" && tokenizer.Next() == html.TextToken {
+					title = strings.TrimSpace(string(tokenizer.Text()))
+				}
+			case "meta":
+				key, content := metaAttrs(token)
+				switch key {
+				case "og:title":
+					preview.Title = content
+				case "og:description":
+					preview.Description = content
+				case "og:site_name":
+					preview.SiteName = content
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if preview.ImageUrl == "" {
+						preview.ImageUrl = resolveImage(base, content)
+					}
+				case "description":
+					description = content
+				}
+			}
+		case html.EndTagToken:
+			if tokenizer.Token().Data == "head" {
+				return finishPreview(preview, title, description)
+			}
+		}
+	}
+}
+
+func metaAttrs(token html.Token) (string, string) {
+	var key, content string
+	for _, attr := range token.Attr {
+		switch attr.Key {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(attr.Val)
+			}
+		case "content":
+			content = strings.TrimSpace(attr.Val)
+		}
+	}
+	return key, content
+}
+
+func resolveImage(base *url.URL, link string) string {
+	image, err := base.Parse(link)
+	if err != nil || (image.Scheme != "http" && image.Scheme != "https") {
+		return ""
+	}
+	return image.String()
+}
+
+func finishPreview(preview *model.LinkPreview, title, description string) *model.LinkPreview {
+	if preview.Title == "" {
+		preview.Title = title
+	}
+	if preview.Description == "" {
+		preview.Description = description
+	}
+	preview.Title = truncateRunes(preview.Title, 300)
+	preview.Description = truncateRunes(preview.Description, 1000)
+	preview.SiteName = truncateRunes(preview.SiteName, 200)
+	if len(preview.ImageUrl) > 2048 {
+		preview.ImageUrl = ""
+	}
+	return preview
+}
+
+func truncateRunes(text string, max int) string {
+	if runes := []rune(text); len(runes) > max {
+		return string(runes[:max])
+	}
+	return text
+}
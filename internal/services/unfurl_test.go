@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testUnfurlConfig(allowPrivate bool) UnfurlConfig {
+	return UnfurlConfig{
+		Timeout:              2 * time.Second,
+		MaxBodyBytes:         64 << 10,
+		MaxRedirects:         3,
+		AllowPrivateNetworks: allowPrivate,
+	}
+}
+
+func TestUnfurlReadsOpenGraph(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head>
+			<title>Fallback title</title>
+			<meta property="og:title" content="  Article title ">
+			<meta name="description" content="Plain description">
+			<meta property="og:image" content="/images/cover.png">
+		</head><body><meta property="og:title" content="ignored"></body></html>`))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/article", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	preview, err := NewOpenGraphUnfurler(testUnfurlConfig(true)).Unfurl(context.Background(), server.URL+"/moved")
+	if err != nil {
+		t.Fatalf("unfurl failed: %v", err)
+	}
+	if preview.Title != "Article title" {
+		t.Errorf("title = %q, want the og:title", preview.Title)
+	}
+	if preview.Description != "Plain description" {
+		t.Errorf("description = %q, want the description meta tag", preview.Description)
+	}
+	if want := server.URL + "/images/cover.png"; preview.ImageUrl != want {
+		t.Errorf("image = %q, want %q resolved against the final URL", preview.ImageUrl, want)
+	}
+	if preview.Url != server.URL+"/moved" {
+		t.Errorf("url = %q, want the posted link", preview.Url)
+	}
+	if host, _, _ := net.SplitHostPort(server.Listener.Addr().String()); preview.SiteName != host {
+		t.Errorf("site name = %q, want the host %q", preview.SiteName, host)
+	}
+}
+
+func TestUnfurlRejectsNonHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewOpenGraphUnfurler(testUnfurlConfig(true)).Unfurl(context.Background(), server.URL); err == nil {
+		t.Fatal("unfurl of a json document succeeded")
+	}
+}
+
+func TestUnfurlRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the default unfurler reached a loopback server")
+	}))
+	defer server.Close()
+
+	_, err := NewOpenGraphUnfurler(testUnfurlConfig(false)).Unfurl(context.Background(), server.URL)
+	if !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("err = %v, want %v", err, errPrivateAddress)
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for address, public := range map[string]bool{
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+	} {
+		if got := isPublicIP(net.ParseIP(address)); got != public {
+			t.Errorf("isPublicIP(%s) = %v, want %v", address, got, public)
+		}
+	}
+}
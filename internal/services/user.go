@@ -4,9 +4,8 @@ import (
 	"context"
 	"errors"
 	"mime/multipart"
-	"os"
-	"path/filepath"
 	"program/internal/model"
+	searchRepo "program/internal/repositories/search"
 	userRepo "program/internal/repositories/user"
 	"time"
 
@@ -14,11 +13,13 @@ import (
 	"github.com/google/uuid"
 )
 
-func NewUserService(repo userRepo.IUserRepo, passHandler *PasswordHandler, auth IJwtAuthService) IUserService {
+func NewUserService(repo userRepo.IUserRepo, passHandler *PasswordHandler, auth IJwtAuthService, storage IFileStorage, search searchRepo.ISearchRepo) IUserService {
 	return &UserService{
 		PassHandler: passHandler,
 		Authen:      auth,
 		repo:        repo,
+		storage:     storage,
+		search:      search,
 	}
 }
 
@@ -37,6 +38,8 @@ type UserService struct {
 	PassHandler *PasswordHandler
 	Authen      IJwtAuthService
 	repo        userRepo.IUserRepo
+	storage     IFileStorage
+	search      searchRepo.ISearchRepo
 }
 
 func (s *UserService) Register(ctx context.Context, registerForm model.Register) (*model.RegisterResponse, error) {
@@ -60,6 +63,7 @@ func (s *UserService) Register(ctx context.Context, registerForm model.Register)
 		Username:  registerForm.Username,
 		Salt:      salt,
 		Hash:      hash,
+		Role:      model.RoleUser,
 		CreatedAt: time.Now(),
 		Deleted:   0,
 	}
@@ -153,12 +157,14 @@ func (s *UserService) CreateUserProfile(ctx context.Context, user_id string, use
 		Address:     userProfilePost.Address,
 		Email:       userProfilePost.Email,
 		PhoneNumber: userProfilePost.PhoneNumber,
+		Private:     userProfilePost.Private,
 		CreatedAt:   time.Now(),
 	}
 
 	if err := s.repo.CreateUserProfle(ctx, &userProfile); err != nil {
 		return nil, err
 	}
+	logSearchError("index", s.search.IndexProfile(ctx, user_id))
 	return &map[string]string{
 		"status":  "successful",
 		"message": userProfile.ProfileId,
@@ -196,6 +202,9 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, user_id string, pro
 	if profilePut.PhoneNumber != "" {
 		fields["phoneNumber"] = profilePut.PhoneNumber
 	}
+	if profilePut.Private != nil {
+		fields["private"] = *profilePut.Private
+	}
 	fields["updatedAt"] = time.Now()
 	if len(fields) == 0 {
 		return nil, errors.New("no fields to update")
@@ -204,18 +213,10 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, user_id string, pro
 	if err != nil {
 		return nil, err
 	}
+	logSearchError("index", s.search.IndexProfile(ctx, user_id))
 	return profile, nil
 }
 
 func (s *UserService) UploadAvatar(ctx *gin.Context, fileUploaded *multipart.FileHeader, filename string) (string, error) {
-	uploadPath := "./uploads/"
-	if _, err := os.Stat(uploadPath); os.IsNotExist(err) {
-		os.MkdirAll(uploadPath, os.ModePerm)
-	}
-
-	fullfilename := filepath.Join(uploadPath, filename)
-	if err := ctx.SaveUploadedFile(fileUploaded, fullfilename); err != nil {
-		return "", err
-	}
-	return fullfilename, nil
+	return s.storage.Save(ctx, fileUploaded, "", filename)
 }
@@ -0,0 +1,38 @@
+package textnorm
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+// Fold lowercases s and strips its diacritics so that Vietnamese text
+// compares equal with or without accents, e.g. "Phở Đà Nẵng" -> "pho da nang".
+func Fold(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if r == 'đ' {
+			r = 'd'
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// Tokenize splits s into folded words
+func Tokenize(s string) []string {
+	return wordPattern.FindAllString(Fold(s), -1)
+}
+
+// WordIndexes returns the byte ranges of the words of s, in the same order as
+// Tokenize returns them.
+func WordIndexes(s string) [][]int {
+	return wordPattern.FindAllStringIndex(s, -1)
+}
@@ -1,17 +1,27 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"os"
 	httpServer "program/internal/api"
 	apiv1 "program/internal/api/v1"
 	"program/internal/database"
 	"program/internal/middleware"
+	"program/internal/model"
 	"strconv"
+	"strings"
+	"time"
 
 	authenticationRepo "program/internal/repositories/auth"
+	migrationRepo "program/internal/repositories/migration"
+	moderationRepo "program/internal/repositories/moderation"
 	newsfeedRepo "program/internal/repositories/newfeed"
+	notificationRepo "program/internal/repositories/notification"
 	relationshipsRepo "program/internal/repositories/relationships"
+	searchRepo "program/internal/repositories/search"
+	timelineRepo "program/internal/repositories/timeline"
 	userRepo "program/internal/repositories/user"
 	"program/internal/services"
 
@@ -64,6 +74,94 @@ func init() {
 	}
 }
 
+// getEnvInt reads an integer setting from the environment, falling back to
+// def when it is unset or malformed
+func getEnvInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+func getEnvFloat(key string, def float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// getEnvList reads a comma separated list, ignoring blank entries
+func getEnvList(key string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, strings.ToLower(value))
+		}
+	}
+	return values
+}
+
+// newSearchRepo selects the search backend, MySQL FULLTEXT by default, with
+// its indexes created on first start, or the embedded "local" Bleve index
+// which is warmed from MySQL before serving
+func newSearchRepo(backend string) searchRepo.ISearchRepo {
+	if backend != "local" {
+		repo := searchRepo.NewMySqlSearchRepo(mySqlConn)
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+		return repo
+	}
+	repo, err := searchRepo.NewLocalSearchRepo(mySqlConn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := repo.Warm(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	return repo
+}
+
+// reconcile prints the counters that drifted from their source tables, and
+// fixes them when run with -fix
+func reconcile(service services.IReconcileService, args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "overwrite drifted counters with their actual values")
+	flags.Parse(args)
+
+	drift, err := service.Reconcile(context.Background(), *fix)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range drift {
+		log.Printf("%s.%s %s: stored %d, actual %d", d.Table, d.Column, d.Id, d.Stored, d.Actual)
+	}
+	switch {
+	case len(drift) == 0:
+		log.Println("no counter drift found")
+	case *fix:
+		log.Printf("fixed %d drifted counters", len(drift))
+	default:
+		log.Printf("found %d drifted counters, run with -fix to repair them", len(drift))
+	}
+}
+
+// migrate applies the pending schema migrations
+func migrate(service services.IMigrateService) {
+	done, err := service.Migrate(context.Background())
+	for _, migration := range done {
+		log.Printf("applied %s: %s", migration.Version, migration.Description)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(done) == 0 {
+		log.Println("the schema is up to date")
+	}
+}
+
 func setup() {
 
 	//Init service
@@ -71,7 +169,7 @@ func setup() {
 }
 func main() {
 	// Init repository
-	authRepo := authenticationRepo.NewAuthenticationRepo(myRedisConn)
+	authRepo := authenticationRepo.NewAuthenticationRepo(myRedisConn, mySqlConn)
 	// Init auth repo config
 	PassHandler := &services.PasswordHandler{SaltSize: 16}
 	auth := &services.JwtAuthService{
@@ -83,12 +181,103 @@ func main() {
 	userRepo := userRepo.NewUserRepo(mySqlConn)
 	relationshipsRepo := relationshipsRepo.NewRelationshipsRepo(mySqlConn)
 	newsfeedRepo := newsfeedRepo.NewNewsfeedRepo(mySqlConn, myRedisConn)
+	timelineRepo := timelineRepo.NewTimelineRepo(myRedisConn)
+	notificationRepo := notificationRepo.NewNotificationRepo(mySqlConn)
+	moderationRepo := moderationRepo.NewModerationRepo(mySqlConn)
+
+	// go run . reconcile [-fix] checks the denormalized counters and exits
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		reconcile(services.NewReconcileService(newsfeedRepo), os.Args[2:])
+		return
+	}
+	// go run . migrate applies the pending schema migrations and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(services.NewMigrateService(migrationRepo.NewMigrationRepo(mySqlConn)))
+		return
+	}
+	searchRepo := newSearchRepo(os.Getenv("SearchBackend"))
 
 	// Init service
+	timelineConfig := services.TimelineConfig{
+		MaxLength:          getEnvInt("TimelineMaxLength", 800),
+		CelebrityThreshold: getEnvInt("CelebrityFollowerThreshold", 10000),
+		WindowDays:         getEnvInt("FeedWindowDays", 7),
+	}
+	timelineService := services.NewTimelineService(timelineConfig, newsfeedRepo, timelineRepo)
 
-	userServices := services.NewUserService(userRepo, PassHandler, auth)
-	relationshipsService := services.NewRelationshipsService(relationshipsRepo)
-	newsfeedService := services.NewNewsFeedService(newsfeedRepo)
+	storage := &services.LocalFileStorage{Root: "./uploads"}
+	notificationService := services.NewNotificationService(notificationRepo)
+	userServices := services.NewUserService(userRepo, PassHandler, auth, storage, searchRepo)
+	relationshipsService := services.NewRelationshipsService(relationshipsRepo, timelineService)
+	rankingWeights := services.DefaultRankingWeights
+	rankingWeights.HalfLifeHours = getEnvFloat("RankingHalfLifeHours", rankingWeights.HalfLifeHours)
+	rankingWeights.Likes = getEnvFloat("RankingWeightLikes", rankingWeights.Likes)
+	rankingWeights.Comments = getEnvFloat("RankingWeightComments", rankingWeights.Comments)
+	rankingWeights.Shares = getEnvFloat("RankingWeightShares", rankingWeights.Shares)
+	rankingWeights.Affinity = getEnvFloat("RankingWeightAffinity", rankingWeights.Affinity)
+	rankingWeights.Mutual = getEnvFloat("RankingWeightMutual", rankingWeights.Mutual)
+	// Add variants here to A/B test ranking strategies
+	ranker := services.NewExperimentRanker(
+		services.NewWeightedRanker("weighted", rankingWeights),
+	)
+	newsfeedConfig := services.NewsfeedConfig{
+		Cache: services.NewsfeedCacheConfig{
+			PageTTL: time.Duration(getEnvInt("NewsfeedCacheTTL", 30)) * time.Second,
+			PostTTL: 5 * time.Minute,
+		},
+		Ranking: services.RankingConfig{
+			CandidatePool: getEnvInt("RankingCandidatePool", 200),
+			AffinityDays:  30,
+		},
+		Media: services.MediaConfig{
+			MaxPerPost:   getEnvInt("MaxMediaPerPost", 10),
+			MaxSizeBytes: int64(getEnvInt("MaxMediaSizeMB", 10)) << 20,
+			AllowedTypes: services.DefaultMediaTypes,
+		},
+		TrendingWindow: time.Duration(getEnvInt("TrendingWindowHours", 24)) * time.Hour,
+	}
+	unfurler := services.NewOpenGraphUnfurler(services.UnfurlConfig{
+		Timeout:      time.Duration(getEnvInt("LinkPreviewTimeoutSeconds", 5)) * time.Second,
+		MaxBodyBytes: int64(getEnvInt("LinkPreviewMaxBodyKB", 512)) << 10,
+		MaxRedirects: 3,
+		UserAgent:    "program-linkpreview/1.0",
+	})
+	linkPreviews := services.NewLinkPreviewWorker(newsfeedRepo, timelineRepo, unfurler, services.LinkPreviewConfig{
+		Workers:    getEnvInt("LinkPreviewWorkers", 2),
+		QueueSize:  1000,
+		CacheTTL:   time.Duration(getEnvInt("LinkPreviewCacheHours", 24)) * time.Hour,
+		FailureTTL: time.Duration(getEnvInt("LinkPreviewFailureMinutes", 10)) * time.Minute,
+	})
+	go linkPreviews.Run(context.Background())
+	// Filters run in order, the ones keeping counters in Redis come last
+	contentFilters := services.NewContentFilterPipeline(moderationRepo,
+		&services.LengthFilter{Limits: map[model.ReportTarget]services.LengthLimit{
+			model.ReportedPost:    {Min: 1, Max: getEnvInt("PostMaxLength", 5000)},
+			model.ReportedComment: {Min: 1, Max: getEnvInt("CommentMaxLength", 2000)},
+		}},
+		services.NewBannedWordFilter(getEnvList("BannedWords"), getEnvList("FlaggedWords")),
+		&services.LinkBlacklistFilter{Domains: getEnvList("BlockedLinkDomains")},
+		&services.RepeatedContentFilter{
+			Counters:   newsfeedRepo,
+			Window:     time.Duration(getEnvInt("RepeatedContentWindowMinutes", 10)) * time.Minute,
+			MaxRepeats: getEnvInt("MaxRepeatedContent", 3),
+		},
+		&services.RateFilter{Counters: newsfeedRepo, Limits: map[model.ReportTarget]services.RateLimit{
+			model.ReportedPost:    {Count: getEnvInt("PostsPerHour", 30), Window: time.Hour},
+			model.ReportedComment: {Count: getEnvInt("CommentsPerMinute", 10), Window: time.Minute},
+		}},
+	)
+	newsfeedService := services.NewNewsFeedService(newsfeedRepo, timelineService, timelineRepo, ranker, storage, notificationService, searchRepo, linkPreviews, contentFilters, newsfeedConfig)
+	searchService := services.NewSearchService(searchRepo, newsfeedService)
+	bookmarkService := services.NewBookmarkService(newsfeedRepo, newsfeedService)
+	feedPreferenceService := services.NewFeedPreferenceService(newsfeedRepo, relationshipsRepo, timelineService, timelineRepo)
+	insightsService := services.NewInsightsService(newsfeedRepo)
+	moderationService := services.NewModerationService(moderationRepo, newsfeedRepo, newsfeedService, authRepo, notificationService, services.ModerationConfig{
+		AutoHideThreshold: getEnvInt("ReportAutoHideThreshold", 5),
+	})
+	schedulerInterval := time.Duration(getEnvInt("SchedulerIntervalSeconds", 30)) * time.Second
+	go services.RunPostScheduler(context.Background(), newsfeedService, schedulerInterval)
+	go services.RunSnoozeSweeper(context.Background(), feedPreferenceService, schedulerInterval)
 
 	// Init middleware service
 	middleware.AuthMdw = middleware.NewAuthorMdw(auth)
@@ -100,6 +289,12 @@ func main() {
 	apiv1.NewUserAPI(server.Engine, userServices)
 	apiv1.NewRelationshipsAPI(server.Engine, relationshipsService)
 	apiv1.NewNewsFeedAPI(server.Engine, newsfeedService)
+	apiv1.NewNotificationAPI(server.Engine, notificationService)
+	apiv1.NewSearchAPI(server.Engine, searchService)
+	apiv1.NewBookmarkAPI(server.Engine, bookmarkService)
+	apiv1.NewFeedPreferenceAPI(server.Engine, feedPreferenceService)
+	apiv1.NewModerationAPI(server.Engine, moderationService)
+	apiv1.NewInsightsAPI(server.Engine, insightsService)
 	//Start http server
 	server.Start("8080")
 }